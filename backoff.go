@@ -0,0 +1,44 @@
+package circuitbreaker
+
+import (
+	"math"
+	"time"
+)
+
+// BackoffPolicy describes a delay that grows exponentially with each
+// consecutive attempt.
+type BackoffPolicy struct {
+	// Initial is the delay before the first attempt. In
+	// Config.RecoveryBackoff it defaults to RecoveryTimeout.
+	Initial time.Duration
+
+	// Multiplier is the factor the delay grows by after each attempt.
+	// Values <= 1 keep the delay at Initial.
+	Multiplier float64
+
+	// Max caps the delay before jitter is applied. Zero means no cap.
+	Max time.Duration
+
+	// Jitter randomises each delay by up to ±Jitter of its value
+	// (0.0–1.0). Zero disables jitter.
+	Jitter float64
+}
+
+// delay returns the delay for the n-th consecutive attempt, counting
+// from zero. rnd is a random number in [0, 1) used for jitter.
+func (b BackoffPolicy) delay(n int, rnd float64) time.Duration {
+	d := float64(b.Initial)
+	if b.Multiplier > 1 && n > 0 {
+		d *= math.Pow(b.Multiplier, float64(n))
+	}
+	if b.Max > 0 && d > float64(b.Max) {
+		d = float64(b.Max)
+	}
+	if b.Jitter > 0 {
+		d += d * b.Jitter * (2*rnd - 1)
+	}
+	if d > math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(d)
+}
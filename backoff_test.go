@@ -0,0 +1,33 @@
+package circuitbreaker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoffPolicy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		policy BackoffPolicy
+		n      int
+		rnd    float64
+		want   time.Duration
+	}{
+		{"first attempt uses Initial", BackoffPolicy{Initial: time.Second, Multiplier: 2}, 0, 0.5, time.Second},
+		{"grows by Multiplier", BackoffPolicy{Initial: time.Second, Multiplier: 2}, 3, 0.5, 8 * time.Second},
+		{"no growth without Multiplier", BackoffPolicy{Initial: time.Second}, 5, 0.5, time.Second},
+		{"capped at Max", BackoffPolicy{Initial: time.Second, Multiplier: 2, Max: 5 * time.Second}, 10, 0.5, 5 * time.Second},
+		{"huge attempt count saturates at Max", BackoffPolicy{Initial: time.Second, Multiplier: 10, Max: time.Hour}, 1000, 0.5, time.Hour},
+		{"jitter lower bound", BackoffPolicy{Initial: 10 * time.Second, Jitter: 0.2}, 0, 0, 8 * time.Second},
+		{"jitter midpoint", BackoffPolicy{Initial: 10 * time.Second, Jitter: 0.2}, 0, 0.5, 10 * time.Second},
+		{"jitter applied after cap", BackoffPolicy{Initial: time.Second, Multiplier: 4, Max: 10 * time.Second, Jitter: 0.5}, 5, 0.75, 12500 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		if got := tt.policy.delay(tt.n, tt.rnd); got != tt.want {
+			t.Errorf("%s: delay(%d) = %v, want %v", tt.name, tt.n, got, tt.want)
+		}
+	}
+}
@@ -2,7 +2,11 @@ package circuitbreaker
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"math/rand"
+	"runtime/debug"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -13,31 +17,152 @@ type Config struct {
 	// Name identifies this breaker in logs and metrics.
 	Name string
 
-	// WindowSize is the number of outcomes kept in the sliding window.
-	// Default: 20.
+	// WindowType selects a count-based or time-based sliding window.
+	// Default: WindowCountBased.
+	WindowType WindowType
+
+	// WindowSize is the number of outcomes kept in the sliding window
+	// when WindowType is WindowCountBased. Default: 20.
 	WindowSize int
 
+	// WindowDuration is the period covered by the sliding window when
+	// WindowType is WindowTimeBased. Default: 60s.
+	WindowDuration time.Duration
+
+	// WindowBuckets is the number of buckets WindowDuration is split
+	// into when WindowType is WindowTimeBased. Outcomes expire one
+	// bucket at a time. Default: 60.
+	WindowBuckets int
+
 	// FailureThreshold is the failure ratio (0.0–1.0) that triggers
-	// the transition from Closed to Open. Default: 0.5.
+	// the transition from Closed to Open. Ignored when TripStrategy is
+	// set. Default: 0.5.
 	FailureThreshold float64
 
+	// SlowCallDuration is the call duration above which a call is
+	// recorded as slow, regardless of its error. Zero disables
+	// slow-call detection. Default: 0.
+	SlowCallDuration time.Duration
+
+	// SlowCallThreshold is the slow-call ratio (0.0–1.0) that triggers
+	// the transition from Closed to Open. Only used when
+	// SlowCallDuration is set; ignored when TripStrategy is set.
+	// Default: 0.5.
+	SlowCallThreshold float64
+
 	// MinRequests is the minimum number of recorded outcomes required
-	// before the breaker can trip. Default: 5.
+	// before the breaker can trip. Ignored when TripStrategy is set.
+	// Default: 5.
 	MinRequests int
 
+	// Mode selects whether the breaker trips (ModeBreaker) or rejects a
+	// share of calls that grows with the backend's failure rate
+	// (ModeThrottle). Default: ModeBreaker.
+	Mode Mode
+
+	// ThrottleK is the multiplier of accepted calls in the ModeThrottle
+	// reject probability. Lower values throttle more aggressively; 2
+	// lets through about twice the traffic the backend accepts. Must be
+	// at least 1. Default: 2.
+	ThrottleK float64
+
+	// TripStrategy decides from the window and the failure streak when
+	// the breaker trips from Closed to Open. Default: a RatioStrategy
+	// built from FailureThreshold, MinRequests and SlowCallThreshold.
+	TripStrategy TripStrategy
+
 	// RecoveryTimeout is how long the breaker stays Open before
 	// transitioning to Half-Open. Default: 30s.
 	RecoveryTimeout time.Duration
 
+	// AutoHalfOpen makes the breaker move from Open to Half-Open with a
+	// timer as soon as the Open period ends, so that OnStateChange, events
+	// and LastStateChange report the transition on time. Otherwise the
+	// transition happens on the first call or State() after the period.
+	// Call Close to stop the timer when discarding the breaker.
+	// Default: false.
+	AutoHalfOpen bool
+
+	// RecoveryBackoff grows the Open duration each time a Half-Open probe
+	// fails, or a ramp-up trips, and the breaker re-opens. Its Initial defaults to
+	// RecoveryTimeout; the duration returns to Initial once the breaker
+	// closes. Default: no growth.
+	RecoveryBackoff BackoffPolicy
+
 	// ProbeCount is the number of successful probe requests required
 	// in Half-Open to transition back to Closed. Default: 3.
 	ProbeCount int
 
-	// Fallback is called instead of returning ErrCircuitOpen when the
-	// breaker is Open. It receives the context and the circuit-open error.
+	// MaxConcurrentProbes is the maximum number of probe requests in
+	// flight while Half-Open. Further requests are rejected with
+	// ErrTooManyProbes. Default: ProbeCount.
+	MaxConcurrentProbes int
+
+	// RampUpDuration adds a StateRecovering phase after the Half-Open
+	// probes succeed. During it the share of requests admitted grows from
+	// 0% to 100% following RampUpCurve, the rest being rejected with
+	// ErrRampingUp, and the breaker re-opens if the TripStrategy fires.
+	// Zero closes the breaker directly. Default: 0.
+	RampUpDuration time.Duration
+
+	// RampUpCurve shapes the growth of the admitted share during
+	// RampUpDuration. Default: RampLinear.
+	RampUpCurve RampCurve
+
+	// MaxConcurrent limits the admitted calls that may run at the same
+	// time. Further calls wait in a queue of up to MaxQueue callers, or
+	// are rejected with ErrBulkheadFull. Zero means no limit. Default: 0.
+	MaxConcurrent int
+
+	// MaxQueue is the number of Execute callers that may wait for a free
+	// slot when MaxConcurrent calls are in flight. Allow never waits.
+	// Default: 0.
+	MaxQueue int
+
+	// QueueTimeout bounds how long Execute waits in the queue before
+	// failing with ErrBulkheadFull. The context's deadline applies too.
+	// Zero waits as long as the context allows. Default: 0.
+	QueueTimeout time.Duration
+
+	// IsFailure reports whether a non-nil error returned by the wrapped
+	// function counts as a failure. Errors for which it returns false are
+	// recorded as successes. Default: every non-nil error is a failure.
+	IsFailure func(err error) bool
+
+	// IgnoreErrors lists errors that are recorded neither as failures nor
+	// as successes. Entries are matched with errors.Is, or with errors.As
+	// when created by ErrorType. Checked before IsFailure.
+	IgnoreErrors []error
+
+	// RecordErrors, when non-empty, lists the only errors recorded as
+	// failures; any other error is recorded as a success. Matched like
+	// IgnoreErrors. Not consulted when IsFailure is set.
+	RecordErrors []error
+
+	// Fallback is called instead of returning an error when the breaker
+	// rejects a call. It receives the context and the rejection error
+	// (ErrCircuitOpen, ErrTooManyProbes, ErrRampingUp, ErrThrottled or
+	// ErrBulkheadFull).
 	Fallback func(ctx context.Context, err error) (any, error)
 
-	// OnStateChange is called whenever the breaker changes state.
+	// Logger receives a record for every state transition, with the
+	// window statistics at the time of the transition, or the probe
+	// counts for transitions out of Half-Open. Nil disables logging.
+	// Default: nil.
+	Logger *slog.Logger
+
+	// Clock provides the time for window expiry, Open timeouts and
+	// metrics. Default: the system clock.
+	Clock Clock
+
+	// RecoverPanics makes Execute return a *PanicError when the wrapped
+	// function panics. Otherwise the panic is re-raised once it has been
+	// recorded. Either way the panic counts as a failure. Default: false.
+	RecoverPanics bool
+
+	// OnStateChange is called whenever the breaker changes state. It runs
+	// synchronously while the breaker is locked, so it must be fast and
+	// must not call back into the breaker; use Subscribe for anything else.
 	OnStateChange func(name string, from, to State)
 }
 
@@ -46,82 +171,300 @@ func (c *Config) withDefaults() Config {
 	if cfg.WindowSize <= 0 {
 		cfg.WindowSize = 20
 	}
+	if cfg.WindowDuration <= 0 {
+		cfg.WindowDuration = 60 * time.Second
+	}
+	if cfg.WindowBuckets <= 0 {
+		cfg.WindowBuckets = 60
+	}
 	if cfg.FailureThreshold <= 0 {
 		cfg.FailureThreshold = 0.5
 	}
+	if cfg.SlowCallThreshold <= 0 {
+		cfg.SlowCallThreshold = 0.5
+	}
 	if cfg.MinRequests <= 0 {
 		cfg.MinRequests = 5
 	}
+	if cfg.ThrottleK <= 0 {
+		cfg.ThrottleK = 2
+	}
+	if cfg.TripStrategy == nil {
+		ratio := RatioStrategy{MinRequests: cfg.MinRequests, FailureThreshold: cfg.FailureThreshold}
+		if cfg.SlowCallDuration > 0 {
+			ratio.SlowCallThreshold = cfg.SlowCallThreshold
+		}
+		cfg.TripStrategy = ratio
+	}
 	if cfg.RecoveryTimeout <= 0 {
 		cfg.RecoveryTimeout = 30 * time.Second
 	}
+	if cfg.RecoveryBackoff.Initial <= 0 {
+		cfg.RecoveryBackoff.Initial = cfg.RecoveryTimeout
+	}
 	if cfg.ProbeCount <= 0 {
 		cfg.ProbeCount = 3
 	}
+	if cfg.MaxConcurrentProbes <= 0 {
+		cfg.MaxConcurrentProbes = cfg.ProbeCount
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = systemClock{}
+	}
 	return cfg
 }
 
+// Validate reports every field of c that holds a value the breaker cannot
+// use, such as a FailureThreshold above 1 or a negative ProbeCount. The
+// errors name their field and are joined with errors.Join. Zero values
+// are valid and mean the default.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(bad bool, format string, args ...any) {
+		if bad {
+			errs = append(errs, fmt.Errorf("circuit breaker: "+format, args...))
+		}
+	}
+	check(c.WindowType != WindowCountBased && c.WindowType != WindowTimeBased,
+		"invalid WindowType %d", c.WindowType)
+	check(c.WindowSize < 0, "WindowSize must not be negative, got %d", c.WindowSize)
+	check(c.WindowDuration < 0, "WindowDuration must not be negative, got %v", c.WindowDuration)
+	check(c.WindowBuckets < 0, "WindowBuckets must not be negative, got %d", c.WindowBuckets)
+	check(c.FailureThreshold < 0 || c.FailureThreshold > 1,
+		"FailureThreshold must be between 0 and 1, got %v", c.FailureThreshold)
+	check(c.SlowCallDuration < 0, "SlowCallDuration must not be negative, got %v", c.SlowCallDuration)
+	check(c.SlowCallThreshold < 0 || c.SlowCallThreshold > 1,
+		"SlowCallThreshold must be between 0 and 1, got %v", c.SlowCallThreshold)
+	check(c.MinRequests < 0, "MinRequests must not be negative, got %d", c.MinRequests)
+	check(c.RecoveryTimeout < 0, "RecoveryTimeout must not be negative, got %v", c.RecoveryTimeout)
+	check(c.RecoveryBackoff.Initial < 0,
+		"RecoveryBackoff.Initial must not be negative, got %v", c.RecoveryBackoff.Initial)
+	check(c.RecoveryBackoff.Multiplier < 0,
+		"RecoveryBackoff.Multiplier must not be negative, got %v", c.RecoveryBackoff.Multiplier)
+	check(c.RecoveryBackoff.Max < 0,
+		"RecoveryBackoff.Max must not be negative, got %v", c.RecoveryBackoff.Max)
+	check(c.RecoveryBackoff.Jitter < 0 || c.RecoveryBackoff.Jitter > 1,
+		"RecoveryBackoff.Jitter must be between 0 and 1, got %v", c.RecoveryBackoff.Jitter)
+	check(c.ProbeCount < 0, "ProbeCount must not be negative, got %d", c.ProbeCount)
+	check(c.MaxConcurrentProbes < 0,
+		"MaxConcurrentProbes must not be negative, got %d", c.MaxConcurrentProbes)
+	check(c.Mode != ModeBreaker && c.Mode != ModeThrottle, "invalid Mode %d", c.Mode)
+	check(c.ThrottleK != 0 && c.ThrottleK < 1, "ThrottleK must be at least 1, got %v", c.ThrottleK)
+	check(c.MaxConcurrent < 0, "MaxConcurrent must not be negative, got %d", c.MaxConcurrent)
+	check(c.MaxQueue < 0, "MaxQueue must not be negative, got %d", c.MaxQueue)
+	check(c.QueueTimeout < 0, "QueueTimeout must not be negative, got %v", c.QueueTimeout)
+	check(c.RampUpDuration < 0, "RampUpDuration must not be negative, got %v", c.RampUpDuration)
+	check(c.RampUpCurve != RampLinear && c.RampUpCurve != RampExponential,
+		"invalid RampUpCurve %d", c.RampUpCurve)
+	if v, ok := c.TripStrategy.(interface{ validate() error }); ok {
+		if err := v.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("circuit breaker: TripStrategy: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // CircuitBreaker protects function calls using the circuit breaker pattern.
 type CircuitBreaker struct {
-	cfg Config
-
-	mu              sync.Mutex
-	state           State
-	window          *slidingWindow
-	openedAt        time.Time
-	lastStateChange time.Time
-	probeSuccesses  int
+	// cfg is replaced, never modified, by UpdateConfig; it is read under
+	// mu, or through the permit issued for a call.
+	cfg   *Config
+	clock Clock // cfg.Clock, which UpdateConfig never changes
+
+	// bulkhead has its own lock and is never replaced.
+	bulkhead *bulkhead
+
+	mu                  sync.Mutex
+	state               State
+	window              outcomeWindow
+	throttle            outcomeWindow // accepted and rejected calls in ModeThrottle; nil otherwise
+	openedAt            time.Time
+	openTimeout         time.Duration // how long the current Open period lasts
+	reopens             int           // consecutive Half-Open→Open transitions
+	rampStart           time.Time     // when StateRecovering was entered
+	halfOpenTimer       Timer         // pending AutoHalfOpen transition
+	lastCall            time.Time     // when a call was last admitted or rejected
+	closed              bool          // set by Close; no more timers are scheduled
+	lastStateChange     time.Time
+	consecutiveFailures int // failures since the last success while Closed
+	probeSuccesses      int // in the current Half-Open period
+	probeFailures       int // in the current Half-Open period
+	probesInFlight      int
+	generation          uint64 // incremented on every state change
 
 	totalRequests  atomic.Int64
 	totalSuccesses atomic.Int64
 	totalFailures  atomic.Int64
-
-	// now is a clock function, overridable for testing.
-	now func() time.Time
+	totalRejects   atomic.Int64
+	totalFallbacks atomic.Int64
+	totalIgnored   atomic.Int64
+	probeSuccess   atomic.Int64
+	probeFailure   atomic.Int64
+
+	events    eventBus
+	parentBus *eventBus // registry bus, set before the breaker is shared
+
+	// rand returns a number in [0, 1) for backoff jitter, overridable
+	// for testing.
+	rand func() float64
 }
 
 // New creates a CircuitBreaker with the given configuration.
-// Zero-value fields in cfg are replaced with sensible defaults.
+// Zero-value fields in cfg are replaced with sensible defaults. Other
+// values are used as given; use NewE to reject invalid ones.
 func New(cfg Config) *CircuitBreaker {
 	cfg = cfg.withDefaults()
+	now := cfg.Clock.Now()
 	return &CircuitBreaker{
-		cfg:             cfg,
+		cfg:             &cfg,
+		clock:           cfg.Clock,
 		state:           StateClosed,
-		window:          newSlidingWindow(cfg.WindowSize),
-		lastStateChange: time.Now(),
-		now:             time.Now,
+		window:          newWindow(cfg, cfg.Clock.Now),
+		throttle:        newThrottleWindow(cfg),
+		bulkhead:        newBulkhead(cfg.MaxConcurrent, cfg.MaxQueue),
+		lastStateChange: now,
+		lastCall:        now,
+		rand:            rand.Float64,
 	}
 }
 
+// NewE is like New, but returns the errors of cfg.Validate instead of
+// creating a breaker when cfg is invalid.
+func NewE(cfg Config) (*CircuitBreaker, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return New(cfg), nil
+}
+
+// now returns the current time according to the configured Clock.
+func (cb *CircuitBreaker) now() time.Time {
+	return cb.clock.Now()
+}
+
 // Execute runs fn through the circuit breaker. If the breaker is Open,
 // it returns ErrCircuitOpen (or calls the fallback if configured).
-// Context cancellation errors are not recorded as failures.
+// Errors are classified by IgnoreErrors, IsFailure and RecordErrors;
+// context cancellation errors are not recorded as failures. A panic in fn
+// is recorded as a failure and then re-raised, or returned as a
+// *PanicError if RecoverPanics is set. A call to runtime.Goexit in fn is
+// recorded as a failure.
 func (cb *CircuitBreaker) Execute(ctx context.Context, fn func(ctx context.Context) (any, error)) (any, error) {
 	cb.totalRequests.Add(1)
 
-	if err := cb.beforeCall(); err != nil {
-		cb.totalFailures.Add(1)
-		if cb.cfg.Fallback != nil {
-			return cb.cfg.Fallback(ctx, err)
+	p, err := cb.beforeCall()
+	if err == nil {
+		err = cb.bulkhead.acquire(ctx, p.cfg.QueueTimeout, cb.clock)
+		if err != nil && !errors.Is(err, ErrBulkheadFull) {
+			// Context was cancelled while queued — don't count this call.
+			cb.release(p)
+			cb.totalIgnored.Add(1)
+			return nil, err
+		}
+		if err != nil {
+			cb.rejectBulkhead(p)
+		}
+	}
+	if err != nil {
+		cb.totalRejects.Add(1)
+		if p.cfg.Fallback != nil {
+			cb.totalFallbacks.Add(1)
+			return p.cfg.Fallback(ctx, err)
 		}
 		return nil, err
 	}
 
-	result, err := fn(ctx)
+	start := cb.now()
+	returned := false
+	defer func() {
+		if !returned {
+			// fn called runtime.Goexit: free the slot and record a failure.
+			cb.bulkhead.release()
+			cb.count(cb.afterCall(p, &PanicError{Stack: debug.Stack()}, cb.now().Sub(start)))
+		}
+	}()
+	result, err, pe := safeCall(ctx, fn)
+	returned = true
+	elapsed := cb.now().Sub(start)
+	cb.bulkhead.release()
+
+	if pe != nil {
+		cb.count(cb.afterCall(p, pe, elapsed))
+		if p.cfg.RecoverPanics {
+			return nil, pe
+		}
+		panic(pe.Value)
+	}
 
 	if err != nil && ctx.Err() != nil {
 		// Context was cancelled — don't count this outcome.
+		cb.release(p)
+		cb.totalIgnored.Add(1)
 		return result, err
 	}
 
-	cb.afterCall(err)
+	cb.count(cb.afterCall(p, err, elapsed))
+
+	return result, err
+}
+
+// safeCall calls fn, recovering a panic into pe.
+func safeCall(ctx context.Context, fn func(ctx context.Context) (any, error)) (result any, err error, pe *PanicError) {
+	defer func() {
+		if v := recover(); v != nil {
+			pe = &PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+	result, err = fn(ctx)
+	return result, err, nil
+}
+
+// Allow is the two-phase alternative to Execute for calls that do not fit
+// in a closure, such as streaming responses whose outcome is only known
+// after the body has been read. If the breaker admits the call, Allow
+// returns a done function that must be called exactly once with the
+// call's error; later calls to done are ignored. If the breaker rejects
+// the call, Allow returns the rejection error, such as ErrCircuitOpen, and
+// a nil done. The Fallback is not used, and with MaxConcurrent set Allow
+// does not queue: it returns ErrBulkheadFull if no slot is free.
+//
+// The call's duration, used for slow-call detection, is measured from
+// Allow to done.
+func (cb *CircuitBreaker) Allow() (done func(err error), err error) {
+	cb.totalRequests.Add(1)
+
+	p, err := cb.beforeCall()
+	if err == nil && !cb.bulkhead.tryAcquire() {
+		cb.rejectBulkhead(p)
+		err = ErrBulkheadFull
+	}
 	if err != nil {
+		cb.totalRejects.Add(1)
+		return nil, err
+	}
+
+	start := cb.now()
+	var reported atomic.Bool
+	return func(err error) {
+		if !reported.CompareAndSwap(false, true) {
+			return
+		}
+		elapsed := cb.now().Sub(start)
+		cb.bulkhead.release()
+		cb.count(cb.afterCall(p, err, elapsed))
+	}, nil
+}
+
+// count updates the outcome counters for a call classified as v.
+func (cb *CircuitBreaker) count(v verdict) {
+	switch v {
+	case verdictFailure:
 		cb.totalFailures.Add(1)
-	} else {
+	case verdictSuccess:
 		cb.totalSuccesses.Add(1)
+	case verdictIgnore:
+		cb.totalIgnored.Add(1)
 	}
-
-	return result, err
 }
 
 // State returns the current state of the circuit breaker.
@@ -130,84 +473,448 @@ func (cb *CircuitBreaker) State() State {
 	defer cb.mu.Unlock()
 
 	// Check if Open has timed out and should become Half-Open.
-	if cb.state == StateOpen && cb.now().Sub(cb.openedAt) >= cb.cfg.RecoveryTimeout {
+	if cb.state == StateOpen && cb.now().Sub(cb.openedAt) >= cb.openTimeout {
 		cb.setState(StateHalfOpen)
 	}
+	cb.checkRamp()
 	return cb.state
 }
 
+// UpdateConfig atomically replaces the breaker's configuration. As in New,
+// zero-value fields are replaced with defaults; Name and Clock cannot be
+// changed and keep their current values. The state and counters are kept.
+// A resized window keeps its most recent outcomes, but a window whose
+// WindowType changes starts empty. An Open period that is in progress
+// keeps its timeout, and enabling AutoHalfOpen schedules its end. If cfg is invalid, UpdateConfig returns the errors of
+// cfg.Validate and changes nothing.
+func (cb *CircuitBreaker) UpdateConfig(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.applyConfig(cfg)
+	return nil
+}
+
+// applyConfig implements UpdateConfig for a validated cfg. The caller
+// holds cb.mu.
+func (cb *CircuitBreaker) applyConfig(cfg Config) {
+	cfg.Name = cb.cfg.Name
+	cfg.Clock = cb.clock
+	cfg = cfg.withDefaults()
+
+	cb.window = resizeWindow(cb.window, cfg, cb.clock.Now)
+	if cb.throttle != nil && cfg.Mode == ModeThrottle {
+		cb.throttle = resizeWindow(cb.throttle, cfg, cb.clock.Now)
+	} else {
+		cb.throttle = newThrottleWindow(cfg)
+	}
+	cb.bulkhead.setLimits(cfg.MaxConcurrent, cfg.MaxQueue)
+	modeChanged := cfg.Mode != cb.cfg.Mode
+	cb.cfg = &cfg
+	if !cfg.AutoHalfOpen {
+		cb.stopHalfOpenTimer()
+	} else if cb.state == StateOpen && cb.halfOpenTimer == nil && !cb.closed {
+		// End the Open period in progress on time, as trip would have.
+		cb.scheduleHalfOpen(max(cb.openTimeout-cb.now().Sub(cb.openedAt), 0))
+	}
+
+	// A throttle has no recovery to wait for.
+	if modeChanged && cfg.Mode == ModeThrottle {
+		switch cb.state {
+		case StateOpen, StateHalfOpen, StateRecovering:
+			cb.setState(StateClosed)
+			cb.resetWindow()
+		}
+	}
+}
+
+// newThrottleWindow returns the window of accepted and rejected calls
+// for cfg, or nil if cfg is not in ModeThrottle.
+func newThrottleWindow(cfg Config) outcomeWindow {
+	if cfg.Mode != ModeThrottle {
+		return nil
+	}
+	return newWindow(cfg, cfg.Clock.Now)
+}
+
+// RemainingOpenTime returns how long the breaker will stay Open before
+// admitting probes. It returns 0 if the breaker is in any other state,
+// including StateForcedOpen, which has no scheduled end.
+func (cb *CircuitBreaker) RemainingOpenTime() time.Duration {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state != StateOpen {
+		return 0
+	}
+	if d := cb.openTimeout - cb.now().Sub(cb.openedAt); d > 0 {
+		return d
+	}
+	return 0
+}
+
 // Metrics returns a snapshot of the breaker's runtime statistics.
 func (cb *CircuitBreaker) Metrics() Metrics {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
 
+	inFlight, queued := cb.bulkhead.counts()
+	var openDuration time.Duration
+	if cb.state == StateOpen {
+		openDuration = cb.openTimeout
+	}
 	return Metrics{
 		TotalRequests:     cb.totalRequests.Load(),
 		TotalSuccesses:    cb.totalSuccesses.Load(),
 		TotalFailures:     cb.totalFailures.Load(),
+		TotalRejections:   cb.totalRejects.Load(),
+		TotalFallbacks:    cb.totalFallbacks.Load(),
+		TotalIgnored:      cb.totalIgnored.Load(),
+		ProbeSuccesses:    cb.probeSuccess.Load(),
+		ProbeFailures:     cb.probeFailure.Load(),
 		CurrentState:      cb.state,
 		LastStateChange:   cb.lastStateChange,
 		WindowFailureRate: cb.window.failureRate(),
+		WindowSlowRate:    cb.window.slowRate(),
+		RejectProbability: cb.rejectProbability(),
+		InFlight:          inFlight,
+		Queued:            queued,
+		OpenDuration:      openDuration,
 	}
 }
 
-// beforeCall checks whether the call is allowed.
-// Returns ErrCircuitOpen if the breaker is Open.
-func (cb *CircuitBreaker) beforeCall() error {
+// ForceOpen pins the breaker in StateForcedOpen, rejecting every call
+// with ErrCircuitOpen until ForceClosed or Reset is called.
+func (cb *CircuitBreaker) ForceOpen() {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
 
-	switch cb.state {
-	case StateClosed:
-		return nil
+	cb.setState(StateForcedOpen)
+}
 
+// Disable puts the breaker in StateDisabled, letting every call through
+// without recording its outcome until ForceClosed or Reset is called.
+func (cb *CircuitBreaker) Disable() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.setState(StateDisabled)
+}
+
+// ForceClosed clears any override and moves the breaker to StateClosed
+// with an empty window. The request counters are kept.
+func (cb *CircuitBreaker) ForceClosed() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.setState(StateClosed)
+	cb.resetWindow()
+}
+
+// Reset clears any override, moves the breaker to StateClosed and
+// resets the window, the recovery backoff and the request counters.
+func (cb *CircuitBreaker) Reset() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.setState(StateClosed)
+	cb.resetWindow()
+	cb.emit(Event{Type: EventReset})
+	cb.openTimeout = 0
+	cb.totalRequests.Store(0)
+	cb.totalSuccesses.Store(0)
+	cb.totalFailures.Store(0)
+	cb.totalRejects.Store(0)
+	cb.totalFallbacks.Store(0)
+	cb.totalIgnored.Store(0)
+	cb.probeSuccess.Store(0)
+	cb.probeFailure.Store(0)
+}
+
+// resetWindow clears the window and all per-period recovery state.
+func (cb *CircuitBreaker) resetWindow() {
+	cb.window.reset()
+	if cb.throttle != nil {
+		cb.throttle.reset()
+	}
+	cb.consecutiveFailures = 0
+	cb.probeSuccesses = 0
+	cb.probeFailures = 0
+	cb.reopens = 0
+}
+
+// permit is issued by beforeCall for every admitted call and handed
+// back with the call's outcome, so that outcomes are only applied to the
+// state they were admitted in.
+type permit struct {
+	generation uint64  // cb.generation when the permit was issued
+	probe      bool    // whether the call holds a Half-Open probe slot
+	cfg        *Config // configuration at the time of the decision
+}
+
+// beforeCall checks whether the call is allowed and issues its permit.
+// Returns ErrCircuitOpen if the breaker is Open, or ErrTooManyProbes if
+// it is Half-Open and all probe slots are taken. The permit's cfg is set
+// even when the call is rejected.
+func (cb *CircuitBreaker) beforeCall() (permit, error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.lastCall = cb.now()
+	p, err := cb.admit()
+	if err != nil {
+		cb.emit(Event{Type: EventRejected, Err: err})
+	}
+	p.cfg = cb.cfg
+	return p, err
+}
+
+// admit implements beforeCall for callers that hold cb.mu.
+func (cb *CircuitBreaker) admit() (permit, error) {
+	switch cb.state {
 	case StateOpen:
-		if cb.now().Sub(cb.openedAt) >= cb.cfg.RecoveryTimeout {
-			cb.setState(StateHalfOpen)
-			return nil // allow probe
+		if cb.now().Sub(cb.openedAt) < cb.openTimeout {
+			return permit{}, ErrCircuitOpen
 		}
-		return ErrCircuitOpen
+		cb.setState(StateHalfOpen)
+		return cb.acquireProbe()
 
 	case StateHalfOpen:
-		return nil // probes allowed
+		return cb.acquireProbe()
+
+	case StateForcedOpen:
+		return permit{}, ErrCircuitOpen
+
+	case StateClosed:
+		if cb.throttle != nil && cb.rand() < cb.rejectProbability() {
+			cb.throttle.record(failure)
+			return permit{}, ErrThrottled
+		}
+
+	case StateRecovering:
+		if cb.checkRamp() {
+			break
+		}
+		if cb.rand() >= cb.cfg.RampUpCurve.admission(cb.rampProgress()) {
+			return permit{}, ErrRampingUp
+		}
 	}
 
-	return nil
+	return permit{generation: cb.generation}, nil
+}
+
+// acquireProbe takes a Half-Open probe slot if one is free.
+func (cb *CircuitBreaker) acquireProbe() (permit, error) {
+	if cb.probesInFlight >= cb.cfg.MaxConcurrentProbes {
+		return permit{}, ErrTooManyProbes
+	}
+	cb.probesInFlight++
+	return permit{generation: cb.generation, probe: true}, nil
+}
+
+// release returns the probe slot held by p without recording an outcome.
+func (cb *CircuitBreaker) release(p permit) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.releaseLocked(p)
 }
 
-// afterCall records the outcome and performs state transitions.
-func (cb *CircuitBreaker) afterCall(err error) {
+// rejectBulkhead releases the permit of a call rejected by the bulkhead
+// and publishes the rejection.
+func (cb *CircuitBreaker) rejectBulkhead(p permit) {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
 
+	cb.releaseLocked(p)
+	cb.emit(Event{Type: EventRejected, Err: ErrBulkheadFull})
+}
+
+// releaseLocked is release for callers that hold cb.mu. Slots from an
+// earlier generation were already reclaimed by the state change.
+func (cb *CircuitBreaker) releaseLocked(p permit) {
+	if p.probe && p.generation == cb.generation {
+		cb.probesInFlight--
+	}
+}
+
+// afterCall classifies and records the outcome of a call that took
+// elapsed, performs state transitions and returns how the call was
+// classified. Ignored calls, and calls admitted before the last state
+// change, leave the breaker untouched.
+func (cb *CircuitBreaker) afterCall(p permit, err error, elapsed time.Duration) verdict {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.releaseLocked(p)
+
+	v := cb.cfg.classify(err)
+	if v == verdictIgnore || p.generation != cb.generation {
+		return v
+	}
+	cb.checkRamp()
+
+	o := success
+	if v == verdictFailure {
+		o |= failure
+	}
+	if cb.cfg.SlowCallDuration > 0 && elapsed > cb.cfg.SlowCallDuration {
+		o |= slowCall
+		cb.emit(Event{Type: EventSlowCall, Duration: elapsed})
+	}
+
 	switch cb.state {
-	case StateClosed:
-		if err != nil {
-			cb.window.record(failure)
+	case StateClosed, StateRecovering:
+		cb.window.record(o)
+		if o.failed() {
+			cb.consecutiveFailures++
 		} else {
-			cb.window.record(success)
+			cb.consecutiveFailures = 0
 		}
 
-		if cb.window.total() >= cb.cfg.MinRequests &&
-			cb.window.failureRate() >= cb.cfg.FailureThreshold {
-			cb.setState(StateOpen)
-			cb.openedAt = cb.now()
+		if cb.throttle != nil {
+			// Slow calls were still accepted by the backend.
+			cb.throttle.record(o & failure)
+			break
+		}
+		if cb.cfg.TripStrategy.ShouldTrip(cb.counts()) {
+			if cb.state == StateRecovering {
+				cb.reopens++
+			}
+			cb.trip()
 		}
 
 	case StateHalfOpen:
-		if err != nil {
-			cb.setState(StateOpen)
-			cb.openedAt = cb.now()
+		cb.emit(Event{Type: EventProbeResult, Success: o == success, Err: err})
+
+		// A slow probe is as much a sign of trouble as a failed one.
+		if o != success {
+			cb.probeFailure.Add(1)
+			cb.probeFailures++
+			cb.reopens++
+			cb.trip()
 			cb.probeSuccesses = 0
+			cb.probeFailures = 0
 		} else {
+			cb.probeSuccess.Add(1)
 			cb.probeSuccesses++
 			if cb.probeSuccesses >= cb.cfg.ProbeCount {
-				cb.setState(StateClosed)
-				cb.window.reset()
-				cb.probeSuccesses = 0
+				cb.recover()
 			}
 		}
 	}
+
+	return v
+}
+
+// recover ends a successful Half-Open period. With RampUpDuration set,
+// the breaker enters StateRecovering with an empty window and keeps its
+// re-open count until the ramp-up ends; otherwise it closes.
+func (cb *CircuitBreaker) recover() {
+	if cb.cfg.RampUpDuration <= 0 {
+		cb.setState(StateClosed)
+		cb.resetWindow()
+		return
+	}
+	cb.setState(StateRecovering)
+	cb.rampStart = cb.now()
+	cb.window.reset()
+	cb.consecutiveFailures = 0
+	cb.probeSuccesses = 0
+	cb.probeFailures = 0
+}
+
+// rampProgress returns how much of the ramp-up has elapsed, from 0 to 1
+// or more.
+func (cb *CircuitBreaker) rampProgress() float64 {
+	if cb.cfg.RampUpDuration <= 0 {
+		return 1
+	}
+	return float64(cb.now().Sub(cb.rampStart)) / float64(cb.cfg.RampUpDuration)
+}
+
+// checkRamp closes the breaker if it is Recovering and the ramp-up has
+// ended, keeping the window. It reports whether it did.
+func (cb *CircuitBreaker) checkRamp() bool {
+	if cb.state != StateRecovering || cb.rampProgress() < 1 {
+		return false
+	}
+	cb.setState(StateClosed)
+	cb.reopens = 0
+	return true
+}
+
+// trip transitions the breaker to Open for a duration chosen by
+// RecoveryBackoff from the number of consecutive re-opens.
+func (cb *CircuitBreaker) trip() {
+	cb.openTimeout = cb.cfg.RecoveryBackoff.delay(cb.reopens, cb.rand())
+	cb.setState(StateOpen)
+	cb.openedAt = cb.now()
+
+	if cb.cfg.AutoHalfOpen && !cb.closed {
+		cb.scheduleHalfOpen(cb.openTimeout)
+	}
+}
+
+// scheduleHalfOpen starts the AutoHalfOpen timer that ends the current
+// Open period after d.
+func (cb *CircuitBreaker) scheduleHalfOpen(d time.Duration) {
+	gen := cb.generation
+	cb.halfOpenTimer = cb.clock.AfterFunc(d, func() {
+		cb.mu.Lock()
+		defer cb.mu.Unlock()
+
+		// Ignore the timer if the breaker has moved on since, for
+		// example through a call that saw the timeout first.
+		if cb.generation == gen && cb.state == StateOpen {
+			cb.setState(StateHalfOpen)
+		}
+	})
+}
+
+// Close stops the timer scheduled by AutoHalfOpen, if any, and prevents
+// new ones. The breaker remains usable, with Open→Half-Open transitions
+// happening lazily. Close is safe to call more than once.
+func (cb *CircuitBreaker) Close() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.closed = true
+	cb.stopHalfOpenTimer()
+}
+
+// idleFor returns how long, on the breaker's Clock, it has not been
+// called since it was last called or created. idle reports whether the
+// breaker is Closed with no call in flight.
+func (cb *CircuitBreaker) idleFor() (d time.Duration, idle bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	inFlight, _ := cb.bulkhead.counts()
+	return cb.now().Sub(cb.lastCall), cb.state == StateClosed && inFlight == 0
+}
+
+// stopHalfOpenTimer cancels a pending AutoHalfOpen transition.
+func (cb *CircuitBreaker) stopHalfOpenTimer() {
+	if cb.halfOpenTimer != nil {
+		cb.halfOpenTimer.Stop()
+		cb.halfOpenTimer = nil
+	}
+}
+
+// counts returns the window and streak counts for the TripStrategy.
+func (cb *CircuitBreaker) counts() Counts {
+	total, fails, slows := cb.window.counts()
+	return Counts{
+		Requests:            total,
+		Failures:            fails,
+		SlowCalls:           slows,
+		ConsecutiveFailures: cb.consecutiveFailures,
+	}
 }
 
 // setState transitions the breaker and fires callbacks/logging.
@@ -219,18 +926,56 @@ func (cb *CircuitBreaker) setState(to State) {
 
 	cb.state = to
 	cb.lastStateChange = cb.now()
+	cb.generation++
+	cb.probesInFlight = 0
+	cb.stopHalfOpenTimer()
+
+	if cb.cfg.Logger != nil {
+		args := []any{"name", cb.cfg.Name, "from", from.String(), "to", to.String()}
+		if from == StateHalfOpen {
+			// Probes, not the window, decide how Half-Open ends.
+			args = append(args,
+				"probe_successes", cb.probeSuccesses,
+				"probe_failures", cb.probeFailures,
+			)
+		} else {
+			total, fails, slows := cb.window.counts()
+			args = append(args,
+				"window_requests", total,
+				"window_failures", fails,
+				"window_slow_calls", slows,
+				"failure_rate", cb.window.failureRate(),
+				"slow_call_rate", cb.window.slowRate(),
+			)
+		}
+		cb.cfg.Logger.Warn("circuit breaker state change", args...)
+	}
 
-	slog.Warn("circuit breaker state change",
-		"name", cb.cfg.Name,
-		"from", from.String(),
-		"to", to.String(),
-	)
+	cb.emit(Event{Type: EventStateChange, From: from, To: to})
 
 	if cb.cfg.OnStateChange != nil {
 		cb.cfg.OnStateChange(cb.cfg.Name, from, to)
 	}
 }
 
+// Subscribe returns a subscription to the breaker's events, buffering up
+// to buffer undelivered events. See Subscription for the drop policy.
+// Call Close on the subscription when done.
+func (cb *CircuitBreaker) Subscribe(buffer int) *Subscription {
+	return cb.events.subscribe(buffer)
+}
+
+// emit publishes ev, stamped with the breaker's name and the current
+// time, to the breaker's subscribers and those of its registry.
+func (cb *CircuitBreaker) emit(ev Event) {
+	ev.Name = cb.cfg.Name
+	ev.Time = cb.now()
+	cb.events.publish(ev)
+	if cb.parentBus != nil {
+		cb.parentBus.publish(ev)
+	}
+}
+
 // Execute is a generic wrapper around CircuitBreaker.Execute that provides
 // type-safe return values.
 func Execute[T any](cb *CircuitBreaker, ctx context.Context, fn func(ctx context.Context) (T, error)) (T, error) {
@@ -1,8 +1,11 @@
 package circuitbreaker
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"log/slog"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -10,15 +13,23 @@ import (
 
 // helper to create a breaker with a controllable clock.
 func newTestBreaker(cfg Config) (*CircuitBreaker, *fakeClock) {
-	cb := New(cfg)
 	fc := &fakeClock{t: time.Now()}
-	cb.now = fc.Now
-	return cb, fc
+	cfg.Clock = fc
+	return New(cfg), fc
 }
 
+// fakeClock is a Clock that only moves when advanced. Timers fire
+// synchronously from Advance.
 type fakeClock struct {
-	mu sync.Mutex
-	t  time.Time
+	mu     sync.Mutex
+	t      time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	fc   *fakeClock
+	when time.Time
+	f    func()
 }
 
 func (fc *fakeClock) Now() time.Time {
@@ -27,10 +38,64 @@ func (fc *fakeClock) Now() time.Time {
 	return fc.t
 }
 
+func (fc *fakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	t := &fakeTimer{fc: fc, when: fc.t.Add(d), f: f}
+	fc.timers = append(fc.timers, t)
+	return t
+}
+
+// Advance steps the clock through the due times of pending timers,
+// running each one, and then to Now()+d.
 func (fc *fakeClock) Advance(d time.Duration) {
+	fc.mu.Lock()
+	target := fc.t.Add(d)
+	fc.mu.Unlock()
+
+	for {
+		fc.mu.Lock()
+		var due *fakeTimer
+		for _, t := range fc.timers {
+			if !t.when.After(target) && (due == nil || t.when.Before(due.when)) {
+				due = t
+			}
+		}
+		if due == nil {
+			fc.t = target
+			fc.mu.Unlock()
+			return
+		}
+		fc.remove(due)
+		if due.when.After(fc.t) {
+			fc.t = due.when
+		}
+		fc.mu.Unlock()
+		due.f()
+	}
+}
+
+// pending returns the number of timers that have not fired or been stopped.
+func (fc *fakeClock) pending() int {
 	fc.mu.Lock()
 	defer fc.mu.Unlock()
-	fc.t = fc.t.Add(d)
+	return len(fc.timers)
+}
+
+func (fc *fakeClock) remove(t *fakeTimer) bool {
+	for i, x := range fc.timers {
+		if x == t {
+			fc.timers = append(fc.timers[:i], fc.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.fc.mu.Lock()
+	defer t.fc.mu.Unlock()
+	return t.fc.remove(t)
 }
 
 var errBoom = errors.New("boom")
@@ -135,6 +200,31 @@ func TestCircuitBreaker(t *testing.T) {
 		}
 	})
 
+	t.Run("RemainingOpenTime: counts down while Open", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newTestBreaker(Config{
+			Name:            "test",
+			WindowSize:      5,
+			MinRequests:     5,
+			RecoveryTimeout: 10 * time.Second,
+		})
+
+		if got := cb.RemainingOpenTime(); got != 0 {
+			t.Fatalf("RemainingOpenTime() = %v while Closed, want 0", got)
+		}
+		for i := 0; i < 5; i++ {
+			cb.Execute(context.Background(), failFn)
+		}
+		fc.Advance(4 * time.Second)
+		if got := cb.RemainingOpenTime(); got != 6*time.Second {
+			t.Fatalf("RemainingOpenTime() = %v, want 6s", got)
+		}
+		fc.Advance(7 * time.Second)
+		if got := cb.RemainingOpenTime(); got != 0 {
+			t.Fatalf("RemainingOpenTime() = %v after timeout, want 0", got)
+		}
+	})
+
 	t.Run("HalfOpen→Closed: all probes succeed", func(t *testing.T) {
 		t.Parallel()
 		cb, fc := newTestBreaker(Config{
@@ -406,6 +496,76 @@ func TestCircuitBreaker(t *testing.T) {
 		}
 	})
 
+	t.Run("Metrics: rejections, fallbacks and ignored calls are counted separately", func(t *testing.T) {
+		t.Parallel()
+		cb, _ := newTestBreaker(Config{
+			Name:            "test",
+			WindowSize:      5,
+			MinRequests:     5,
+			RecoveryTimeout: time.Minute,
+			Fallback: func(context.Context, error) (any, error) {
+				return "fallback", nil
+			},
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		cb.Execute(ctx, func(ctx context.Context) (any, error) { return nil, ctx.Err() })
+
+		for i := 0; i < 5; i++ {
+			cb.Execute(context.Background(), failFn)
+		}
+		for i := 0; i < 3; i++ {
+			cb.Execute(context.Background(), succeedFn) // rejected, served by fallback
+		}
+
+		m := cb.Metrics()
+		if m.TotalRequests != 9 {
+			t.Errorf("TotalRequests = %d, want 9", m.TotalRequests)
+		}
+		if m.TotalFailures != 5 {
+			t.Errorf("TotalFailures = %d, want 5", m.TotalFailures)
+		}
+		if m.TotalRejections != 3 {
+			t.Errorf("TotalRejections = %d, want 3", m.TotalRejections)
+		}
+		if m.TotalFallbacks != 3 {
+			t.Errorf("TotalFallbacks = %d, want 3", m.TotalFallbacks)
+		}
+		if m.TotalIgnored != 1 {
+			t.Errorf("TotalIgnored = %d, want 1", m.TotalIgnored)
+		}
+	})
+
+	t.Run("Metrics: probe outcomes are counted", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newTestBreaker(Config{
+			Name:            "test",
+			WindowSize:      5,
+			MinRequests:     5,
+			RecoveryTimeout: 10 * time.Second,
+			ProbeCount:      2,
+		})
+
+		for i := 0; i < 5; i++ {
+			cb.Execute(context.Background(), failFn)
+		}
+		fc.Advance(11 * time.Second)
+		cb.Execute(context.Background(), succeedFn)
+		cb.Execute(context.Background(), failFn)
+		fc.Advance(11 * time.Second)
+		cb.Execute(context.Background(), succeedFn)
+		cb.Execute(context.Background(), succeedFn)
+
+		m := cb.Metrics()
+		if m.ProbeSuccesses != 3 || m.ProbeFailures != 1 {
+			t.Fatalf("probes = %d/%d, want 3 successes and 1 failure", m.ProbeSuccesses, m.ProbeFailures)
+		}
+		if m.CurrentState != StateClosed {
+			t.Fatalf("CurrentState = %v, want Closed", m.CurrentState)
+		}
+	})
+
 	t.Run("Closed→Open resets on HalfOpen→Closed transition", func(t *testing.T) {
 		t.Parallel()
 		cb, fc := newTestBreaker(Config{
@@ -440,6 +600,1095 @@ func TestCircuitBreaker(t *testing.T) {
 	})
 }
 
+func TestCircuitBreakerTimeWindow(t *testing.T) {
+	t.Parallel()
+
+	t.Run("old failures expire and do not trip the breaker", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newTestBreaker(Config{
+			Name:             "test",
+			WindowType:       WindowTimeBased,
+			WindowDuration:   time.Minute,
+			WindowBuckets:    60,
+			FailureThreshold: 0.5,
+			MinRequests:      5,
+		})
+
+		for i := 0; i < 4; i++ {
+			cb.Execute(context.Background(), failFn)
+		}
+
+		// 40 minutes later the earlier failures are out of the window.
+		fc.Advance(40 * time.Minute)
+		cb.Execute(context.Background(), failFn)
+
+		if cb.State() != StateClosed {
+			t.Fatalf("state = %v, want Closed (old failures expired)", cb.State())
+		}
+		if m := cb.Metrics(); m.WindowFailureRate != 1 {
+			t.Fatalf("WindowFailureRate = %v, want 1", m.WindowFailureRate)
+		}
+	})
+
+	t.Run("failures within the window trip the breaker", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newTestBreaker(Config{
+			Name:             "test",
+			WindowType:       WindowTimeBased,
+			WindowDuration:   time.Minute,
+			FailureThreshold: 0.5,
+			MinRequests:      5,
+		})
+
+		for i := 0; i < 5; i++ {
+			cb.Execute(context.Background(), failFn)
+			fc.Advance(10 * time.Second)
+		}
+
+		if cb.State() != StateOpen {
+			t.Fatalf("state = %v, want Open", cb.State())
+		}
+	})
+}
+
+func TestCircuitBreakerSlowCalls(t *testing.T) {
+	t.Parallel()
+
+	// slowFn returns a function that succeeds after advancing fc by d.
+	slowFn := func(fc *fakeClock, d time.Duration) func(context.Context) (any, error) {
+		return func(context.Context) (any, error) {
+			fc.Advance(d)
+			return "ok", nil
+		}
+	}
+
+	t.Run("Closed→Open: slow-call ratio exceeds threshold", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newTestBreaker(Config{
+			Name:              "test",
+			WindowSize:        10,
+			MinRequests:       5,
+			SlowCallDuration:  time.Second,
+			SlowCallThreshold: 0.6,
+		})
+
+		for i := 0; i < 5; i++ {
+			if _, err := cb.Execute(context.Background(), slowFn(fc, 9*time.Second)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		if cb.State() != StateOpen {
+			t.Fatalf("state = %v, want Open", cb.State())
+		}
+		if m := cb.Metrics(); m.WindowSlowRate != 1 || m.WindowFailureRate != 0 {
+			t.Fatalf("slow rate = %v, failure rate = %v, want 1 and 0",
+				m.WindowSlowRate, m.WindowFailureRate)
+		}
+	})
+
+	t.Run("fast calls are not slow", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newTestBreaker(Config{
+			Name:             "test",
+			MinRequests:      5,
+			SlowCallDuration: time.Second,
+		})
+
+		for i := 0; i < 10; i++ {
+			cb.Execute(context.Background(), slowFn(fc, 100*time.Millisecond))
+		}
+
+		if cb.State() != StateClosed {
+			t.Fatalf("state = %v, want Closed", cb.State())
+		}
+		if m := cb.Metrics(); m.WindowSlowRate != 0 {
+			t.Fatalf("WindowSlowRate = %v, want 0", m.WindowSlowRate)
+		}
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newTestBreaker(Config{Name: "test", MinRequests: 5})
+
+		for i := 0; i < 10; i++ {
+			cb.Execute(context.Background(), slowFn(fc, time.Hour))
+		}
+
+		if cb.State() != StateClosed {
+			t.Fatalf("state = %v, want Closed", cb.State())
+		}
+	})
+
+	t.Run("HalfOpen→Open: slow probe", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newTestBreaker(Config{
+			Name:             "test",
+			WindowSize:       5,
+			MinRequests:      5,
+			RecoveryTimeout:  10 * time.Second,
+			SlowCallDuration: time.Second,
+		})
+
+		for i := 0; i < 5; i++ {
+			cb.Execute(context.Background(), failFn)
+		}
+		fc.Advance(11 * time.Second)
+
+		cb.Execute(context.Background(), slowFn(fc, 2*time.Second))
+
+		if cb.State() != StateOpen {
+			t.Fatalf("state = %v, want Open", cb.State())
+		}
+	})
+}
+
+func TestCircuitBreakerProbeLimit(t *testing.T) {
+	t.Parallel()
+
+	// tripAndRecover opens the breaker and advances past RecoveryTimeout.
+	tripAndRecover := func(cb *CircuitBreaker, fc *fakeClock) {
+		for i := 0; i < 5; i++ {
+			cb.Execute(context.Background(), failFn)
+		}
+		fc.Advance(11 * time.Second)
+	}
+
+	// startProbe runs a probe in the background that blocks until release
+	// is closed and then returns err. It waits until the probe is admitted.
+	startProbe := func(cb *CircuitBreaker, release <-chan struct{}, err error, done *sync.WaitGroup) {
+		admitted := make(chan struct{})
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			cb.Execute(context.Background(), func(context.Context) (any, error) {
+				close(admitted)
+				<-release
+				return nil, err
+			})
+		}()
+		<-admitted
+	}
+
+	t.Run("HalfOpen: rejects probes beyond the limit", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newTestBreaker(Config{
+			Name:                "test",
+			WindowSize:          5,
+			MinRequests:         5,
+			RecoveryTimeout:     10 * time.Second,
+			ProbeCount:          2,
+			MaxConcurrentProbes: 2,
+		})
+		tripAndRecover(cb, fc)
+
+		release := make(chan struct{})
+		var wg sync.WaitGroup
+		startProbe(cb, release, nil, &wg)
+		startProbe(cb, release, nil, &wg)
+
+		_, err := cb.Execute(context.Background(), succeedFn)
+		if !errors.Is(err, ErrTooManyProbes) {
+			t.Fatalf("err = %v, want ErrTooManyProbes", err)
+		}
+
+		close(release)
+		wg.Wait()
+
+		if cb.State() != StateClosed {
+			t.Fatalf("state = %v, want Closed", cb.State())
+		}
+	})
+
+	t.Run("HalfOpen: finished probes free their slot", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newTestBreaker(Config{
+			Name:                "test",
+			WindowSize:          5,
+			MinRequests:         5,
+			RecoveryTimeout:     10 * time.Second,
+			ProbeCount:          3,
+			MaxConcurrentProbes: 1,
+		})
+		tripAndRecover(cb, fc)
+
+		for i := 0; i < 3; i++ {
+			if _, err := cb.Execute(context.Background(), succeedFn); err != nil {
+				t.Fatalf("probe %d: unexpected error: %v", i, err)
+			}
+		}
+		if cb.State() != StateClosed {
+			t.Fatalf("state = %v, want Closed", cb.State())
+		}
+	})
+
+	t.Run("HalfOpen: cancelled probe frees its slot", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newTestBreaker(Config{
+			Name:                "test",
+			WindowSize:          5,
+			MinRequests:         5,
+			RecoveryTimeout:     10 * time.Second,
+			MaxConcurrentProbes: 1,
+		})
+		tripAndRecover(cb, fc)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		cb.Execute(ctx, func(ctx context.Context) (any, error) {
+			return nil, ctx.Err()
+		})
+
+		if _, err := cb.Execute(context.Background(), succeedFn); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("HalfOpen: stale probe outcome is not applied", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newTestBreaker(Config{
+			Name:                "test",
+			WindowSize:          5,
+			MinRequests:         5,
+			RecoveryTimeout:     10 * time.Second,
+			ProbeCount:          1,
+			MaxConcurrentProbes: 2,
+		})
+		tripAndRecover(cb, fc)
+
+		// A slow probe is admitted, then a second probe fails and re-opens.
+		release := make(chan struct{})
+		var wg sync.WaitGroup
+		startProbe(cb, release, nil, &wg)
+		cb.Execute(context.Background(), failFn)
+
+		// The first probe's success belongs to the previous Half-Open
+		// period and must not close the breaker.
+		close(release)
+		wg.Wait()
+
+		if cb.State() != StateOpen {
+			t.Fatalf("state = %v, want Open", cb.State())
+		}
+	})
+}
+
+func TestCircuitBreakerRecoveryBackoff(t *testing.T) {
+	t.Parallel()
+
+	newBackoffBreaker := func() (*CircuitBreaker, *fakeClock) {
+		return newTestBreaker(Config{
+			Name:            "test",
+			WindowSize:      5,
+			MinRequests:     5,
+			RecoveryTimeout: 10 * time.Second,
+			ProbeCount:      1,
+			RecoveryBackoff: BackoffPolicy{
+				Multiplier: 2,
+				Max:        35 * time.Second,
+			},
+		})
+	}
+
+	// reopen waits out the current Open period and fails the probe.
+	reopen := func(t *testing.T, cb *CircuitBreaker, fc *fakeClock, wait time.Duration) {
+		t.Helper()
+		fc.Advance(wait - time.Second)
+		if cb.State() != StateOpen {
+			t.Fatalf("state = %v before %v elapsed, want Open", cb.State(), wait)
+		}
+		fc.Advance(time.Second)
+		cb.Execute(context.Background(), failFn)
+		if cb.State() != StateOpen {
+			t.Fatalf("state = %v, want Open", cb.State())
+		}
+	}
+
+	t.Run("Open duration grows on consecutive probe failures", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newBackoffBreaker()
+
+		for i := 0; i < 5; i++ {
+			cb.Execute(context.Background(), failFn)
+		}
+		if got := cb.Metrics().OpenDuration; got != 10*time.Second {
+			t.Fatalf("OpenDuration = %v, want 10s", got)
+		}
+
+		reopen(t, cb, fc, 10*time.Second)
+		reopen(t, cb, fc, 20*time.Second)
+		if got := cb.Metrics().OpenDuration; got != 35*time.Second {
+			t.Fatalf("OpenDuration = %v, want 35s (capped)", got)
+		}
+		reopen(t, cb, fc, 35*time.Second)
+	})
+
+	t.Run("backoff resets once Closed", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newBackoffBreaker()
+
+		for i := 0; i < 5; i++ {
+			cb.Execute(context.Background(), failFn)
+		}
+		reopen(t, cb, fc, 10*time.Second)
+
+		fc.Advance(20 * time.Second)
+		cb.Execute(context.Background(), succeedFn)
+		if cb.State() != StateClosed {
+			t.Fatalf("state = %v, want Closed", cb.State())
+		}
+		if got := cb.Metrics().OpenDuration; got != 0 {
+			t.Fatalf("OpenDuration = %v while Closed, want 0", got)
+		}
+
+		for i := 0; i < 5; i++ {
+			cb.Execute(context.Background(), failFn)
+		}
+		if got := cb.Metrics().OpenDuration; got != 10*time.Second {
+			t.Fatalf("OpenDuration = %v, want 10s after reset", got)
+		}
+	})
+}
+
+func TestCircuitBreakerOverrides(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ForceOpen: rejects until cleared, even past RecoveryTimeout", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newTestBreaker(Config{Name: "test", RecoveryTimeout: 10 * time.Second})
+
+		cb.ForceOpen()
+		fc.Advance(time.Hour)
+
+		if cb.State() != StateForcedOpen {
+			t.Fatalf("state = %v, want ForcedOpen", cb.State())
+		}
+		if _, err := cb.Execute(context.Background(), succeedFn); !errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("err = %v, want ErrCircuitOpen", err)
+		}
+
+		cb.ForceClosed()
+		if _, err := cb.Execute(context.Background(), succeedFn); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cb.State() != StateClosed {
+			t.Fatalf("state = %v, want Closed", cb.State())
+		}
+	})
+
+	t.Run("Disable: calls pass and outcomes are not recorded", func(t *testing.T) {
+		t.Parallel()
+		cb, _ := newTestBreaker(Config{Name: "test", WindowSize: 5, MinRequests: 5})
+
+		cb.Disable()
+		for i := 0; i < 10; i++ {
+			if _, err := cb.Execute(context.Background(), failFn); !errors.Is(err, errBoom) {
+				t.Fatalf("err = %v, want errBoom", err)
+			}
+		}
+
+		if cb.State() != StateDisabled {
+			t.Fatalf("state = %v, want Disabled", cb.State())
+		}
+		if m := cb.Metrics(); m.WindowFailureRate != 0 {
+			t.Fatalf("WindowFailureRate = %v, want 0", m.WindowFailureRate)
+		}
+	})
+
+	t.Run("ForceClosed: clears the window of a tripped breaker", func(t *testing.T) {
+		t.Parallel()
+		cb, _ := newTestBreaker(Config{Name: "test", WindowSize: 5, MinRequests: 5})
+
+		for i := 0; i < 5; i++ {
+			cb.Execute(context.Background(), failFn)
+		}
+		cb.ForceClosed()
+
+		m := cb.Metrics()
+		if m.CurrentState != StateClosed || m.WindowFailureRate != 0 {
+			t.Fatalf("metrics = %+v, want Closed with empty window", m)
+		}
+		if m.TotalFailures != 5 {
+			t.Fatalf("TotalFailures = %d, want 5 (counters kept)", m.TotalFailures)
+		}
+	})
+
+	t.Run("Reset: clears counters", func(t *testing.T) {
+		t.Parallel()
+		cb, _ := newTestBreaker(Config{Name: "test", WindowSize: 5, MinRequests: 5})
+
+		for i := 0; i < 5; i++ {
+			cb.Execute(context.Background(), failFn)
+		}
+		cb.Reset()
+
+		m := cb.Metrics()
+		if m.CurrentState != StateClosed || m.TotalRequests != 0 || m.TotalFailures != 0 || m.WindowFailureRate != 0 {
+			t.Fatalf("metrics = %+v, want zeroed Closed breaker", m)
+		}
+	})
+
+	t.Run("OnStateChange: fires for overrides", func(t *testing.T) {
+		t.Parallel()
+
+		var mu sync.Mutex
+		var got []State
+		cb, _ := newTestBreaker(Config{
+			Name: "test",
+			OnStateChange: func(_ string, _, to State) {
+				mu.Lock()
+				got = append(got, to)
+				mu.Unlock()
+			},
+		})
+
+		cb.ForceOpen()
+		cb.Disable()
+		cb.ForceClosed()
+
+		mu.Lock()
+		defer mu.Unlock()
+		want := []State{StateForcedOpen, StateDisabled, StateClosed}
+		if len(got) != len(want) {
+			t.Fatalf("transitions = %v, want %v", got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("transitions = %v, want %v", got, want)
+			}
+		}
+	})
+}
+
+func TestCircuitBreakerAllow(t *testing.T) {
+	t.Parallel()
+
+	t.Run("done records the outcome", func(t *testing.T) {
+		t.Parallel()
+		cb, _ := newTestBreaker(Config{Name: "test", WindowSize: 5, MinRequests: 5})
+
+		for i := 0; i < 5; i++ {
+			done, err := cb.Allow()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			done(errBoom)
+		}
+
+		if cb.State() != StateOpen {
+			t.Fatalf("state = %v, want Open", cb.State())
+		}
+		if _, err := cb.Allow(); !errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("err = %v, want ErrCircuitOpen", err)
+		}
+
+		m := cb.Metrics()
+		if m.TotalRequests != 6 || m.TotalFailures != 5 || m.TotalRejections != 1 {
+			t.Fatalf("metrics = %+v, want 6 requests, 5 failures and 1 rejection", m)
+		}
+	})
+
+	t.Run("done reports only once", func(t *testing.T) {
+		t.Parallel()
+		cb, _ := newTestBreaker(Config{Name: "test", WindowSize: 5, MinRequests: 5})
+
+		done, err := cb.Allow()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		done(nil)
+		for i := 0; i < 10; i++ {
+			done(errBoom)
+		}
+
+		m := cb.Metrics()
+		if m.TotalSuccesses != 1 || m.TotalFailures != 0 || m.WindowFailureRate != 0 {
+			t.Fatalf("metrics = %+v, want a single success", m)
+		}
+	})
+
+	t.Run("duration is measured from Allow to done", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newTestBreaker(Config{
+			Name:             "test",
+			WindowSize:       5,
+			MinRequests:      5,
+			SlowCallDuration: time.Second,
+		})
+
+		done, _ := cb.Allow()
+		fc.Advance(2 * time.Second)
+		done(nil)
+
+		if m := cb.Metrics(); m.WindowSlowRate != 1 {
+			t.Fatalf("WindowSlowRate = %v, want 1", m.WindowSlowRate)
+		}
+	})
+
+	t.Run("HalfOpen: done releases the probe slot", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newTestBreaker(Config{
+			Name:                "test",
+			WindowSize:          5,
+			MinRequests:         5,
+			RecoveryTimeout:     10 * time.Second,
+			ProbeCount:          2,
+			MaxConcurrentProbes: 1,
+		})
+		for i := 0; i < 5; i++ {
+			cb.Execute(context.Background(), failFn)
+		}
+		fc.Advance(11 * time.Second)
+
+		done, err := cb.Allow()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := cb.Allow(); !errors.Is(err, ErrTooManyProbes) {
+			t.Fatalf("err = %v, want ErrTooManyProbes", err)
+		}
+		done(nil)
+		done(nil) // must not release a second slot
+
+		done2, err := cb.Allow()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := cb.Allow(); !errors.Is(err, ErrTooManyProbes) {
+			t.Fatalf("err = %v, want ErrTooManyProbes", err)
+		}
+		done2(nil)
+
+		if cb.State() != StateClosed {
+			t.Fatalf("state = %v, want Closed", cb.State())
+		}
+	})
+}
+
+func TestCircuitBreakerPanics(t *testing.T) {
+	t.Parallel()
+
+	panicFn := func(context.Context) (any, error) { panic("kaboom") }
+
+	t.Run("panic is recorded as a failure and re-raised", func(t *testing.T) {
+		t.Parallel()
+		cb, _ := newTestBreaker(Config{Name: "test", WindowSize: 5, MinRequests: 5})
+
+		for i := 0; i < 5; i++ {
+			func() {
+				defer func() {
+					if v := recover(); v != "kaboom" {
+						t.Fatalf("recovered %v, want kaboom", v)
+					}
+				}()
+				cb.Execute(context.Background(), panicFn)
+			}()
+		}
+
+		if cb.State() != StateOpen {
+			t.Fatalf("state = %v, want Open", cb.State())
+		}
+		if m := cb.Metrics(); m.TotalFailures != 5 {
+			t.Fatalf("TotalFailures = %d, want 5", m.TotalFailures)
+		}
+	})
+
+	t.Run("RecoverPanics returns a PanicError", func(t *testing.T) {
+		t.Parallel()
+		cb, _ := newTestBreaker(Config{Name: "test", RecoverPanics: true})
+
+		_, err := cb.Execute(context.Background(), panicFn)
+		var pe *PanicError
+		if !errors.As(err, &pe) {
+			t.Fatalf("err = %v, want *PanicError", err)
+		}
+		if pe.Value != "kaboom" || len(pe.Stack) == 0 {
+			t.Fatalf("PanicError = %+v, want value and stack", pe)
+		}
+		if m := cb.Metrics(); m.TotalFailures != 1 || m.WindowFailureRate != 1 {
+			t.Fatalf("metrics = %+v, want one failure", m)
+		}
+	})
+
+	t.Run("panic is a failure even if the classifier disagrees", func(t *testing.T) {
+		t.Parallel()
+		cb, _ := newTestBreaker(Config{
+			Name:          "test",
+			RecoverPanics: true,
+			IsFailure:     func(error) bool { return false },
+		})
+
+		cb.Execute(context.Background(), panicFn)
+
+		if m := cb.Metrics(); m.TotalFailures != 1 {
+			t.Fatalf("TotalFailures = %d, want 1", m.TotalFailures)
+		}
+	})
+
+	t.Run("HalfOpen: panicking probe re-opens and frees its slot", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newTestBreaker(Config{
+			Name:                "test",
+			WindowSize:          5,
+			MinRequests:         5,
+			RecoveryTimeout:     10 * time.Second,
+			MaxConcurrentProbes: 1,
+			RecoverPanics:       true,
+		})
+		for i := 0; i < 5; i++ {
+			cb.Execute(context.Background(), failFn)
+		}
+		fc.Advance(11 * time.Second)
+
+		cb.Execute(context.Background(), panicFn)
+		if cb.State() != StateOpen {
+			t.Fatalf("state = %v, want Open", cb.State())
+		}
+
+		fc.Advance(11 * time.Second)
+		if _, err := cb.Execute(context.Background(), succeedFn); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("PanicError unwraps error values", func(t *testing.T) {
+		t.Parallel()
+		cb, _ := newTestBreaker(Config{Name: "test", RecoverPanics: true})
+
+		_, err := cb.Execute(context.Background(), func(context.Context) (any, error) {
+			panic(errBoom)
+		})
+		if !errors.Is(err, errBoom) {
+			t.Fatalf("err = %v, want errBoom", err)
+		}
+	})
+}
+
+func TestCircuitBreakerLogger(t *testing.T) {
+	t.Parallel()
+
+	t.Run("transitions are logged with window statistics", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		cb, _ := newTestBreaker(Config{
+			Name:        "logged",
+			WindowSize:  5,
+			MinRequests: 5,
+			Logger:      slog.New(slog.NewTextHandler(&buf, nil)),
+		})
+
+		for i := 0; i < 5; i++ {
+			cb.Execute(context.Background(), failFn)
+		}
+
+		out := buf.String()
+		for _, want := range []string{
+			`msg="circuit breaker state change"`,
+			"name=logged",
+			"from=closed",
+			"to=open",
+			"window_requests=5",
+			"window_failures=5",
+			"failure_rate=1",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("log output missing %q:\n%s", want, out)
+			}
+		}
+	})
+
+	t.Run("Half-Open transitions are logged with probe counts", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		cb, fc := newTestBreaker(Config{
+			Name:            "logged",
+			WindowSize:      5,
+			MinRequests:     5,
+			RecoveryTimeout: time.Second,
+			ProbeCount:      2,
+			Logger:          slog.New(slog.NewTextHandler(&buf, nil)),
+		})
+
+		for i := 0; i < 5; i++ {
+			cb.Execute(context.Background(), failFn)
+		}
+		fc.Advance(time.Second)
+		cb.Execute(context.Background(), succeedFn)
+		cb.Execute(context.Background(), succeedFn)
+
+		var line string
+		for _, l := range strings.Split(buf.String(), "\n") {
+			if strings.Contains(l, "from=half-open") {
+				line = l
+			}
+		}
+		for _, want := range []string{"to=closed", "probe_successes=2", "probe_failures=0"} {
+			if !strings.Contains(line, want) {
+				t.Errorf("Half-Open log line missing %q: %q", want, line)
+			}
+		}
+		if strings.Contains(line, "failure_rate") {
+			t.Errorf("Half-Open log line has stale window statistics: %q", line)
+		}
+	})
+
+	t.Run("registry default logger is used by its breakers", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		r := NewRegistry(Config{Logger: slog.New(slog.NewTextHandler(&buf, nil))})
+
+		r.Get("svc").ForceOpen()
+
+		if !strings.Contains(buf.String(), "name=svc") {
+			t.Fatalf("log output = %q, want a record for svc", buf.String())
+		}
+	})
+}
+
+func TestCircuitBreakerAutoHalfOpen(t *testing.T) {
+	t.Parallel()
+
+	newAutoBreaker := func(onChange func(string, State, State)) (*CircuitBreaker, *fakeClock) {
+		return newTestBreaker(Config{
+			Name:            "test",
+			WindowSize:      5,
+			MinRequests:     5,
+			RecoveryTimeout: 10 * time.Second,
+			AutoHalfOpen:    true,
+			OnStateChange:   onChange,
+		})
+	}
+
+	trip := func(cb *CircuitBreaker) {
+		for i := 0; i < 5; i++ {
+			cb.Execute(context.Background(), failFn)
+		}
+	}
+
+	t.Run("transition happens on time without calls", func(t *testing.T) {
+		t.Parallel()
+
+		var mu sync.Mutex
+		var at []time.Time
+		var fc *fakeClock
+		cb, fc := newAutoBreaker(func(_ string, _, to State) {
+			if to == StateHalfOpen {
+				mu.Lock()
+				at = append(at, fc.Now())
+				mu.Unlock()
+			}
+		})
+		trip(cb)
+		openedAt := fc.Now()
+
+		fc.Advance(time.Minute)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if len(at) != 1 {
+			t.Fatalf("got %d Half-Open transitions, want 1", len(at))
+		}
+		if want := openedAt.Add(10 * time.Second); !at[0].Equal(want) {
+			t.Fatalf("transition at %v, want %v", at[0], want)
+		}
+		if got := cb.Metrics().LastStateChange; !got.Equal(openedAt.Add(10 * time.Second)) {
+			t.Fatalf("LastStateChange = %v, want %v", got, openedAt.Add(10*time.Second))
+		}
+	})
+
+	t.Run("timer is cancelled when the state changes first", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newAutoBreaker(nil)
+		trip(cb)
+
+		cb.ForceOpen()
+		if got := fc.pending(); got != 0 {
+			t.Fatalf("pending timers = %d, want 0", got)
+		}
+		fc.Advance(time.Minute)
+		if cb.State() != StateForcedOpen {
+			t.Fatalf("state = %v, want ForcedOpen", cb.State())
+		}
+	})
+
+	t.Run("backoff schedules the timer for the grown duration", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newTestBreaker(Config{
+			Name:            "test",
+			WindowSize:      5,
+			MinRequests:     5,
+			RecoveryTimeout: 10 * time.Second,
+			RecoveryBackoff: BackoffPolicy{Multiplier: 3},
+			AutoHalfOpen:    true,
+		})
+		trip(cb)
+		fc.Advance(10 * time.Second)
+		cb.Execute(context.Background(), failFn) // probe fails → 30s
+
+		// Metrics does not trigger the lazy transition, so it shows
+		// whether the timer fired.
+		fc.Advance(29 * time.Second)
+		if got := cb.Metrics().CurrentState; got != StateOpen {
+			t.Fatalf("state = %v, want Open", got)
+		}
+		fc.Advance(time.Second)
+		if got := cb.Metrics().CurrentState; got != StateHalfOpen {
+			t.Fatalf("state = %v, want HalfOpen", got)
+		}
+	})
+
+	t.Run("Close stops the timer", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newAutoBreaker(nil)
+		trip(cb)
+
+		cb.Close()
+		cb.Close()
+		if got := fc.pending(); got != 0 {
+			t.Fatalf("pending timers = %d, want 0", got)
+		}
+
+		// Still usable: the transition now happens lazily.
+		fc.Advance(time.Minute)
+		if _, err := cb.Execute(context.Background(), succeedFn); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		trip(cb)
+		if got := fc.pending(); got != 0 {
+			t.Fatalf("pending timers after Close = %d, want 0", got)
+		}
+	})
+}
+
+func TestConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("zero config is valid", func(t *testing.T) {
+		t.Parallel()
+		if err := (&Config{}).Validate(); err != nil {
+			t.Fatalf("Validate() = %v, want nil", err)
+		}
+	})
+
+	t.Run("each invalid field is reported", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			field string
+			cfg   Config
+		}{
+			{"WindowType", Config{WindowType: WindowType(7)}},
+			{"WindowSize", Config{WindowSize: -1}},
+			{"WindowDuration", Config{WindowDuration: -time.Second}},
+			{"WindowBuckets", Config{WindowBuckets: -1}},
+			{"FailureThreshold", Config{FailureThreshold: 1.5}},
+			{"FailureThreshold", Config{FailureThreshold: -0.1}},
+			{"SlowCallDuration", Config{SlowCallDuration: -time.Second}},
+			{"SlowCallThreshold", Config{SlowCallThreshold: 2}},
+			{"MinRequests", Config{MinRequests: -5}},
+			{"RecoveryTimeout", Config{RecoveryTimeout: -time.Second}},
+			{"RecoveryBackoff.Initial", Config{RecoveryBackoff: BackoffPolicy{Initial: -time.Second}}},
+			{"RecoveryBackoff.Multiplier", Config{RecoveryBackoff: BackoffPolicy{Multiplier: -2}}},
+			{"RecoveryBackoff.Max", Config{RecoveryBackoff: BackoffPolicy{Max: -time.Second}}},
+			{"RecoveryBackoff.Jitter", Config{RecoveryBackoff: BackoffPolicy{Jitter: 1.1}}},
+			{"ProbeCount", Config{ProbeCount: -1}},
+			{"MaxConcurrentProbes", Config{MaxConcurrentProbes: -1}},
+		}
+		for _, tt := range tests {
+			err := tt.cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.field) {
+				t.Errorf("Validate(%+v) = %v, want error mentioning %s", tt.cfg, err, tt.field)
+			}
+		}
+	})
+
+	t.Run("all errors are joined", func(t *testing.T) {
+		t.Parallel()
+		err := (&Config{FailureThreshold: 1.5, ProbeCount: -1}).Validate()
+		if err == nil {
+			t.Fatal("Validate() = nil, want error")
+		}
+		if got := len(strings.Split(err.Error(), "\n")); got != 2 {
+			t.Fatalf("Validate() = %q, want 2 errors", err)
+		}
+	})
+
+	t.Run("NewE rejects an invalid config", func(t *testing.T) {
+		t.Parallel()
+		cb, err := NewE(Config{FailureThreshold: 1.5})
+		if cb != nil || err == nil || !strings.Contains(err.Error(), "FailureThreshold") {
+			t.Fatalf("NewE = %v, %v; want nil and a FailureThreshold error", cb, err)
+		}
+
+		cb, err = NewE(Config{Name: "ok"})
+		if err != nil || cb == nil {
+			t.Fatalf("NewE = %v, %v; want a breaker", cb, err)
+		}
+	})
+}
+
+func TestCircuitBreakerUpdateConfig(t *testing.T) {
+	t.Parallel()
+
+	t.Run("new threshold applies to the existing window", func(t *testing.T) {
+		t.Parallel()
+		cb, _ := newTestBreaker(Config{Name: "test", WindowSize: 10, MinRequests: 5, FailureThreshold: 0.9})
+
+		// 3 failures, 2 successes: 60% is below 90%.
+		for i := 0; i < 3; i++ {
+			cb.Execute(context.Background(), failFn)
+		}
+		for i := 0; i < 2; i++ {
+			cb.Execute(context.Background(), succeedFn)
+		}
+
+		if err := cb.UpdateConfig(Config{WindowSize: 10, MinRequests: 5, FailureThreshold: 0.5}); err != nil {
+			t.Fatalf("UpdateConfig: %v", err)
+		}
+		cb.Execute(context.Background(), succeedFn)
+
+		if cb.State() != StateOpen {
+			t.Fatalf("state = %v, want Open", cb.State())
+		}
+	})
+
+	t.Run("shrinking the window keeps the most recent outcomes", func(t *testing.T) {
+		t.Parallel()
+		cb, _ := newTestBreaker(Config{Name: "test", WindowSize: 20, MinRequests: 20})
+
+		for i := 0; i < 4; i++ {
+			cb.Execute(context.Background(), failFn)
+		}
+		for i := 0; i < 4; i++ {
+			cb.Execute(context.Background(), succeedFn)
+		}
+
+		if err := cb.UpdateConfig(Config{WindowSize: 4, MinRequests: 20}); err != nil {
+			t.Fatalf("UpdateConfig: %v", err)
+		}
+		if m := cb.Metrics(); m.WindowFailureRate != 0 || m.TotalRequests != 8 {
+			t.Fatalf("metrics = %+v, want empty failure rate and counters kept", m)
+		}
+	})
+
+	t.Run("changing WindowType starts an empty window", func(t *testing.T) {
+		t.Parallel()
+		cb, _ := newTestBreaker(Config{Name: "test", WindowSize: 10, MinRequests: 10})
+
+		for i := 0; i < 5; i++ {
+			cb.Execute(context.Background(), failFn)
+		}
+		if err := cb.UpdateConfig(Config{WindowType: WindowTimeBased, MinRequests: 10}); err != nil {
+			t.Fatalf("UpdateConfig: %v", err)
+		}
+		if got := cb.Metrics().WindowFailureRate; got != 0 {
+			t.Fatalf("WindowFailureRate = %v, want 0", got)
+		}
+	})
+
+	t.Run("Name and Clock are kept", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newTestBreaker(Config{Name: "test"})
+
+		if err := cb.UpdateConfig(Config{Name: "other", RecoveryTimeout: time.Minute}); err != nil {
+			t.Fatalf("UpdateConfig: %v", err)
+		}
+		cb.mu.Lock()
+		name, clock := cb.cfg.Name, cb.cfg.Clock
+		cb.mu.Unlock()
+		if name != "test" || clock != Clock(fc) {
+			t.Fatalf("Name = %q, Clock = %v; want originals", name, clock)
+		}
+	})
+
+	t.Run("invalid config is rejected and not applied", func(t *testing.T) {
+		t.Parallel()
+		cb, _ := newTestBreaker(Config{Name: "test", WindowSize: 5, MinRequests: 5})
+
+		err := cb.UpdateConfig(Config{FailureThreshold: 1.5, MinRequests: -1})
+		if err == nil {
+			t.Fatal("UpdateConfig: want error")
+		}
+		for _, field := range []string{"FailureThreshold", "MinRequests"} {
+			if !strings.Contains(err.Error(), field) {
+				t.Errorf("error %q does not mention %s", err, field)
+			}
+		}
+
+		for i := 0; i < 5; i++ {
+			cb.Execute(context.Background(), failFn)
+		}
+		if cb.State() != StateOpen {
+			t.Fatalf("state = %v, want Open (old config kept)", cb.State())
+		}
+	})
+
+	t.Run("disabling AutoHalfOpen stops the timer", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newTestBreaker(Config{Name: "test", WindowSize: 5, MinRequests: 5, AutoHalfOpen: true})
+
+		for i := 0; i < 5; i++ {
+			cb.Execute(context.Background(), failFn)
+		}
+		if got := fc.pending(); got != 1 {
+			t.Fatalf("pending timers = %d, want 1", got)
+		}
+		if err := cb.UpdateConfig(Config{WindowSize: 5, MinRequests: 5}); err != nil {
+			t.Fatalf("UpdateConfig: %v", err)
+		}
+		if got := fc.pending(); got != 0 {
+			t.Fatalf("pending timers = %d, want 0", got)
+		}
+	})
+
+	t.Run("enabling AutoHalfOpen ends the Open period on time", func(t *testing.T) {
+		t.Parallel()
+		cfg := Config{Name: "test", WindowSize: 5, MinRequests: 5, RecoveryTimeout: 10 * time.Second}
+		cb, fc := newTestBreaker(cfg)
+
+		for i := 0; i < 5; i++ {
+			cb.Execute(context.Background(), failFn)
+		}
+		fc.Advance(4 * time.Second)
+
+		cfg.AutoHalfOpen = true
+		if err := cb.UpdateConfig(cfg); err != nil {
+			t.Fatalf("UpdateConfig: %v", err)
+		}
+		if got := fc.pending(); got != 1 {
+			t.Fatalf("pending timers = %d, want 1", got)
+		}
+
+		// Metrics reads the state without the lazy Open→Half-Open check.
+		fc.Advance(5 * time.Second)
+		if got := cb.Metrics().CurrentState; got != StateOpen {
+			t.Fatalf("state = %v after 9s, want Open", got)
+		}
+		fc.Advance(time.Second)
+		if got := cb.Metrics().CurrentState; got != StateHalfOpen {
+			t.Fatalf("state = %v after 10s, want HalfOpen", got)
+		}
+	})
+
+	t.Run("Concurrent access: updates during calls", func(t *testing.T) {
+		t.Parallel()
+		cb := New(Config{Name: "test", Fallback: func(context.Context, error) (any, error) { return nil, nil }})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				cb.Execute(context.Background(), failFn)
+			}()
+			go func(i int) {
+				defer wg.Done()
+				cb.UpdateConfig(Config{WindowSize: 5 + i%10, RecoverPanics: i%2 == 0})
+			}(i)
+		}
+		wg.Wait()
+	})
+}
+
 func TestStateString(t *testing.T) {
 	t.Parallel()
 
@@ -450,6 +1699,9 @@ func TestStateString(t *testing.T) {
 		{StateClosed, "closed"},
 		{StateOpen, "open"},
 		{StateHalfOpen, "half-open"},
+		{StateForcedOpen, "forced-open"},
+		{StateDisabled, "disabled"},
+		{StateRecovering, "recovering"},
 		{State(99), "unknown"},
 	}
 
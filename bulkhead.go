@@ -0,0 +1,142 @@
+package circuitbreaker
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// bulkhead limits the number of calls in flight, queueing up to maxQueue
+// callers in FIFO order when the limit is reached. A zero max means no
+// limit; the in-flight count is still tracked for Metrics.
+type bulkhead struct {
+	mu       sync.Mutex
+	max      int
+	maxQueue int
+	inFlight int
+	waiters  []*bulkheadWaiter
+}
+
+// bulkheadWaiter is a queued caller. ready is closed when the caller is
+// handed a slot.
+type bulkheadWaiter struct {
+	ready chan struct{}
+}
+
+func newBulkhead(max, maxQueue int) *bulkhead {
+	return &bulkhead{max: max, maxQueue: maxQueue}
+}
+
+// setLimits changes the limits, granting slots to queued callers if max
+// was raised. Callers already in flight or queued beyond the new limits
+// keep their place.
+func (b *bulkhead) setLimits(max, maxQueue int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.max = max
+	b.maxQueue = maxQueue
+	for len(b.waiters) > 0 && b.free() {
+		b.inFlight++
+		b.grant()
+	}
+}
+
+// free reports whether a call can start without exceeding max.
+func (b *bulkhead) free() bool {
+	return b.max <= 0 || b.inFlight < b.max
+}
+
+// grant hands the next slot to the first queued caller. The slot must
+// already be counted in inFlight.
+func (b *bulkhead) grant() {
+	w := b.waiters[0]
+	b.waiters[0] = nil
+	b.waiters = b.waiters[1:]
+	close(w.ready)
+}
+
+// tryAcquire takes a slot if one is free and nobody is queued.
+func (b *bulkhead) tryAcquire() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.free() || len(b.waiters) > 0 {
+		return false
+	}
+	b.inFlight++
+	return true
+}
+
+// acquire takes a slot, queueing for at most timeout (zero: no limit)
+// if none is free. It returns ErrBulkheadFull if the queue is full or
+// the timeout expires, and ctx.Err() if ctx is done first.
+func (b *bulkhead) acquire(ctx context.Context, timeout time.Duration, clock Clock) error {
+	b.mu.Lock()
+	if b.free() && len(b.waiters) == 0 {
+		b.inFlight++
+		b.mu.Unlock()
+		return nil
+	}
+	if len(b.waiters) >= b.maxQueue {
+		b.mu.Unlock()
+		return ErrBulkheadFull
+	}
+	w := &bulkheadWaiter{ready: make(chan struct{})}
+	b.waiters = append(b.waiters, w)
+	b.mu.Unlock()
+
+	var expired chan struct{}
+	if timeout > 0 {
+		expired = make(chan struct{})
+		t := clock.AfterFunc(timeout, func() { close(expired) })
+		defer t.Stop()
+	}
+
+	var err error
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-expired:
+		err = ErrBulkheadFull
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i, q := range b.waiters {
+		if q == w {
+			b.waiters = append(b.waiters[:i], b.waiters[i+1:]...)
+			return err
+		}
+	}
+	// The slot was granted while giving up; pass it on.
+	b.releaseLocked()
+	return err
+}
+
+// release frees a slot taken by acquire or tryAcquire.
+func (b *bulkhead) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.releaseLocked()
+}
+
+func (b *bulkhead) releaseLocked() {
+	b.inFlight--
+	if len(b.waiters) > 0 && b.free() {
+		b.inFlight++
+		b.grant()
+	}
+}
+
+// counts returns the number of calls in flight and queued.
+func (b *bulkhead) counts() (inFlight, queued int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.inFlight, len(b.waiters)
+}
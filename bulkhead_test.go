@@ -0,0 +1,293 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// waitFor polls cond until it holds, failing the test after a second.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestBulkhead(t *testing.T) {
+	t.Parallel()
+
+	queued := func(b *bulkhead) int {
+		_, q := b.counts()
+		return q
+	}
+
+	t.Run("rejects when full and the queue is full", func(t *testing.T) {
+		t.Parallel()
+		b := newBulkhead(1, 0)
+
+		if err := b.acquire(context.Background(), 0, systemClock{}); err != nil {
+			t.Fatalf("acquire: %v", err)
+		}
+		if err := b.acquire(context.Background(), 0, systemClock{}); !errors.Is(err, ErrBulkheadFull) {
+			t.Fatalf("err = %v, want ErrBulkheadFull", err)
+		}
+		if b.tryAcquire() {
+			t.Fatal("tryAcquire succeeded with no free slot")
+		}
+
+		b.release()
+		if !b.tryAcquire() {
+			t.Fatal("tryAcquire failed with a free slot")
+		}
+	})
+
+	t.Run("queued callers are served in order", func(t *testing.T) {
+		t.Parallel()
+		b := newBulkhead(1, 2)
+		b.acquire(context.Background(), 0, systemClock{})
+
+		order := make(chan int, 2)
+		for i := 1; i <= 2; i++ {
+			i := i
+			go func() {
+				b.acquire(context.Background(), 0, systemClock{})
+				order <- i
+			}()
+			waitFor(t, "caller to queue", func() bool { return queued(b) == i })
+		}
+
+		b.release()
+		if got := <-order; got != 1 {
+			t.Fatalf("first served = %d, want 1", got)
+		}
+		b.release()
+		if got := <-order; got != 2 {
+			t.Fatalf("second served = %d, want 2", got)
+		}
+		if inFlight, q := b.counts(); inFlight != 1 || q != 0 {
+			t.Fatalf("counts = %d, %d; want 1, 0", inFlight, q)
+		}
+	})
+
+	t.Run("queue timeout and context cancellation", func(t *testing.T) {
+		t.Parallel()
+		fc := &fakeClock{t: time.Now()}
+		b := newBulkhead(1, 2)
+		b.acquire(context.Background(), 0, fc)
+
+		timedOut := make(chan error, 1)
+		go func() { timedOut <- b.acquire(context.Background(), time.Second, fc) }()
+		waitFor(t, "caller to queue", func() bool { return queued(b) == 1 && fc.pending() == 1 })
+		fc.Advance(time.Second)
+		if err := <-timedOut; !errors.Is(err, ErrBulkheadFull) {
+			t.Fatalf("err = %v, want ErrBulkheadFull", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancelled := make(chan error, 1)
+		go func() { cancelled <- b.acquire(ctx, 0, fc) }()
+		waitFor(t, "caller to queue", func() bool { return queued(b) == 1 })
+		cancel()
+		if err := <-cancelled; !errors.Is(err, context.Canceled) {
+			t.Fatalf("err = %v, want context.Canceled", err)
+		}
+
+		if inFlight, q := b.counts(); inFlight != 1 || q != 0 {
+			t.Fatalf("counts = %d, %d; want 1, 0", inFlight, q)
+		}
+	})
+
+	t.Run("raising the limit admits queued callers", func(t *testing.T) {
+		t.Parallel()
+		b := newBulkhead(1, 1)
+		b.acquire(context.Background(), 0, systemClock{})
+
+		done := make(chan error, 1)
+		go func() { done <- b.acquire(context.Background(), 0, systemClock{}) }()
+		waitFor(t, "caller to queue", func() bool { return queued(b) == 1 })
+
+		b.setLimits(2, 1)
+		if err := <-done; err != nil {
+			t.Fatalf("acquire: %v", err)
+		}
+	})
+}
+
+func TestCircuitBreakerBulkhead(t *testing.T) {
+	t.Parallel()
+
+	// block returns a function that runs until release is closed.
+	block := func(started chan<- struct{}, release <-chan struct{}) func(context.Context) (any, error) {
+		return func(context.Context) (any, error) {
+			started <- struct{}{}
+			<-release
+			return "ok", nil
+		}
+	}
+
+	t.Run("rejects with ErrBulkheadFull and reports in-flight and queued calls", func(t *testing.T) {
+		t.Parallel()
+		cb := New(Config{Name: "test", MaxConcurrent: 1, MaxQueue: 1})
+
+		started := make(chan struct{}, 2)
+		release := make(chan struct{})
+		results := make(chan error, 2)
+		go func() {
+			_, err := cb.Execute(context.Background(), block(started, release))
+			results <- err
+		}()
+		<-started
+		go func() {
+			_, err := cb.Execute(context.Background(), block(started, release))
+			results <- err
+		}()
+		waitFor(t, "call to queue", func() bool { return cb.Metrics().Queued == 1 })
+
+		if m := cb.Metrics(); m.InFlight != 1 {
+			t.Fatalf("InFlight = %d, want 1", m.InFlight)
+		}
+
+		if _, err := cb.Execute(context.Background(), succeedFn); !errors.Is(err, ErrBulkheadFull) {
+			t.Fatalf("err = %v, want ErrBulkheadFull", err)
+		}
+
+		close(release)
+		for i := 0; i < 2; i++ {
+			if err := <-results; err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		m := cb.Metrics()
+		if m.InFlight != 0 || m.Queued != 0 || m.TotalRejections != 1 || m.TotalSuccesses != 2 {
+			t.Fatalf("metrics = %+v, want 2 successes, 1 rejection, nothing in flight", m)
+		}
+		if m.CurrentState != StateClosed || m.WindowFailureRate != 0 {
+			t.Fatalf("metrics = %+v, want rejections kept out of the window", m)
+		}
+	})
+
+	t.Run("Fallback receives ErrBulkheadFull", func(t *testing.T) {
+		t.Parallel()
+		var fallbackErr error
+		cb := New(Config{Name: "test", MaxConcurrent: 1, Fallback: func(_ context.Context, err error) (any, error) {
+			fallbackErr = err
+			return "fallback", nil
+		}})
+
+		started := make(chan struct{}, 1)
+		release := make(chan struct{})
+		defer close(release)
+		go cb.Execute(context.Background(), block(started, release))
+		<-started
+
+		got, err := cb.Execute(context.Background(), succeedFn)
+		if got != "fallback" || err != nil || !errors.Is(fallbackErr, ErrBulkheadFull) {
+			t.Fatalf("Execute = %v, %v (fallback got %v); want fallback with ErrBulkheadFull", got, err, fallbackErr)
+		}
+	})
+
+	t.Run("cancelled while queued is ignored", func(t *testing.T) {
+		t.Parallel()
+		cb := New(Config{Name: "test", MaxConcurrent: 1, MaxQueue: 1})
+
+		started := make(chan struct{}, 1)
+		release := make(chan struct{})
+		defer close(release)
+		go cb.Execute(context.Background(), block(started, release))
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if _, err := cb.Execute(ctx, succeedFn); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("err = %v, want DeadlineExceeded", err)
+		}
+		if m := cb.Metrics(); m.TotalIgnored != 1 || m.TotalRejections != 0 {
+			t.Fatalf("metrics = %+v, want 1 ignored, 0 rejections", m)
+		}
+	})
+
+	t.Run("Goexit in fn frees the slot", func(t *testing.T) {
+		t.Parallel()
+		cb := New(Config{Name: "test", MaxConcurrent: 1})
+
+		exited := make(chan struct{})
+		go func() {
+			defer close(exited)
+			cb.Execute(context.Background(), func(context.Context) (any, error) {
+				runtime.Goexit()
+				return nil, nil
+			})
+		}()
+		<-exited
+
+		if m := cb.Metrics(); m.InFlight != 0 || m.TotalFailures != 1 {
+			t.Fatalf("metrics = %+v, want nothing in flight and 1 failure", m)
+		}
+		if _, err := cb.Execute(context.Background(), succeedFn); err != nil {
+			t.Fatalf("Execute after Goexit: %v", err)
+		}
+	})
+
+	t.Run("Allow does not queue", func(t *testing.T) {
+		t.Parallel()
+		cb := New(Config{Name: "test", MaxConcurrent: 1, MaxQueue: 10})
+
+		done, err := cb.Allow()
+		if err != nil {
+			t.Fatalf("Allow: %v", err)
+		}
+		if _, err := cb.Allow(); !errors.Is(err, ErrBulkheadFull) {
+			t.Fatalf("err = %v, want ErrBulkheadFull", err)
+		}
+		done(nil)
+		done(nil)
+		if m := cb.Metrics(); m.InFlight != 0 {
+			t.Fatalf("InFlight = %d, want 0", m.InFlight)
+		}
+		if _, err := cb.Allow(); err != nil {
+			t.Fatalf("Allow after done: %v", err)
+		}
+	})
+
+	t.Run("HalfOpen: bulkhead rejection frees the probe slot", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newTestBreaker(Config{
+			Name:                "test",
+			WindowSize:          5,
+			MinRequests:         5,
+			RecoveryTimeout:     time.Second,
+			ProbeCount:          2,
+			MaxConcurrentProbes: 2,
+			MaxConcurrent:       1,
+		})
+		for i := 0; i < 5; i++ {
+			cb.Execute(context.Background(), failFn)
+		}
+		fc.Advance(time.Second)
+
+		done, err := cb.Allow()
+		if err != nil {
+			t.Fatalf("Allow: %v", err)
+		}
+		// With a leaked slot the second rejection would be ErrTooManyProbes.
+		for i := 0; i < 2; i++ {
+			if _, err := cb.Allow(); !errors.Is(err, ErrBulkheadFull) {
+				t.Fatalf("err = %v, want ErrBulkheadFull", err)
+			}
+		}
+		done(nil)
+		if _, err := cb.Execute(context.Background(), succeedFn); err != nil {
+			t.Fatalf("second probe: %v", err)
+		}
+		if cb.State() != StateClosed {
+			t.Fatalf("state = %v, want Closed", cb.State())
+		}
+	})
+}
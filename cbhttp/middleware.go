@@ -0,0 +1,128 @@
+package cbhttp
+
+import (
+	"bufio"
+	"math"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+
+	circuitbreaker "github.com/awasame/circuitbreaker"
+)
+
+// Middleware returns server middleware that runs each request's handler
+// through the breaker in reg named by key(req). Handler responses with a
+// status >= 500 are reported to the breaker as a *StatusError, and
+// handler panics as a *circuitbreaker.PanicError, which is always a
+// failure, before the panic is propagated. The ResponseWriter passed to
+// the handler implements http.Flusher and http.Hijacker.
+//
+// When the breaker rejects a request, the handler is not called and the
+// client receives 503 Service Unavailable with a Retry-After header
+// derived from the breaker's remaining Open time. The breaker's Fallback
+// is not used.
+func Middleware(reg *circuitbreaker.Registry, key func(req *http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cb := reg.Get(key(r))
+
+			done, err := cb.Allow()
+			if err != nil {
+				w.Header().Set("Retry-After", retryAfter(cb))
+				http.Error(w, err.Error(), http.StatusServiceUnavailable)
+				return
+			}
+
+			rec := &statusRecorder{ResponseWriter: w}
+			panicked := true
+			defer func() {
+				if !panicked {
+					return
+				}
+				// v is nil if the handler called runtime.Goexit.
+				v := recover()
+				done(&circuitbreaker.PanicError{Value: v, Stack: debug.Stack()})
+				if v != nil {
+					panic(v)
+				}
+			}()
+
+			next.ServeHTTP(rec, r)
+			panicked = false
+
+			if rec.status() >= 500 {
+				done(&StatusError{StatusCode: rec.status()})
+				return
+			}
+			done(nil)
+		})
+	}
+}
+
+// MethodPathKey returns the request method and URL path, e.g.
+// "GET /users/42", giving one breaker per method and path. For routes
+// with path parameters, prefer a key that returns the route pattern.
+func MethodPathKey(req *http.Request) string {
+	return req.Method + " " + req.URL.Path
+}
+
+// retryAfter returns the Retry-After value, in whole seconds, for a
+// request rejected by cb. Breakers without a scheduled end of the Open
+// period (Half-Open, forced-open) ask the client to retry after 1s.
+func retryAfter(cb *circuitbreaker.CircuitBreaker) string {
+	secs := int64(math.Ceil(cb.RemainingOpenTime().Seconds()))
+	if secs < 1 {
+		secs = 1
+	}
+	return strconv.FormatInt(secs, 10)
+}
+
+// statusRecorder captures the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if r.code == 0 {
+		r.code = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.code == 0 {
+		r.code = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher for streaming handlers. It does nothing
+// if the underlying writer cannot flush.
+func (r *statusRecorder) Flush() {
+	if r.code == 0 {
+		r.code = http.StatusOK
+	}
+	http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+// Hijack implements http.Hijacker. It returns an error wrapping
+// http.ErrNotSupported if the underlying writer cannot be hijacked.
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(r.ResponseWriter).Hijack()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// status returns the status code the handler sent, which is 200 if it
+// wrote nothing.
+func (r *statusRecorder) status() int {
+	if r.code == 0 {
+		return http.StatusOK
+	}
+	return r.code
+}
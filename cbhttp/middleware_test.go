@@ -0,0 +1,178 @@
+package cbhttp
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	circuitbreaker "github.com/awasame/circuitbreaker"
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	newRegistry := func() *circuitbreaker.Registry {
+		return circuitbreaker.NewRegistry(circuitbreaker.Config{
+			WindowSize:      5,
+			MinRequests:     5,
+			RecoveryTimeout: 30 * time.Second,
+		})
+	}
+
+	serve := func(h http.Handler, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	t.Run("5xx responses trip the breaker and shed load", func(t *testing.T) {
+		t.Parallel()
+		reg := newRegistry()
+		calls := 0
+		h := Middleware(reg, MethodPathKey)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			calls++
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+
+		for i := 0; i < 5; i++ {
+			if rec := serve(h, "/orders"); rec.Code != http.StatusInternalServerError {
+				t.Fatalf("status = %d, want 500", rec.Code)
+			}
+		}
+
+		rec := serve(h, "/orders")
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Fatalf("status = %d, want 503", rec.Code)
+		}
+		if got := rec.Header().Get("Retry-After"); got != "30" {
+			t.Fatalf("Retry-After = %q, want 30", got)
+		}
+		if calls != 5 {
+			t.Fatalf("handler calls = %d, want 5", calls)
+		}
+		if reg.Get("GET /orders").State() != circuitbreaker.StateOpen {
+			t.Fatal("breaker for GET /orders should be Open")
+		}
+	})
+
+	t.Run("routes have independent breakers", func(t *testing.T) {
+		t.Parallel()
+		reg := newRegistry()
+		h := Middleware(reg, MethodPathKey)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/broken" {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.Write([]byte("ok"))
+		}))
+
+		for i := 0; i < 10; i++ {
+			serve(h, "/broken")
+		}
+
+		if rec := serve(h, "/healthy"); rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", rec.Code)
+		}
+	})
+
+	t.Run("4xx responses are successes", func(t *testing.T) {
+		t.Parallel()
+		reg := newRegistry()
+		h := Middleware(reg, MethodPathKey)(http.NotFoundHandler())
+
+		for i := 0; i < 10; i++ {
+			if rec := serve(h, "/missing"); rec.Code != http.StatusNotFound {
+				t.Fatalf("status = %d, want 404", rec.Code)
+			}
+		}
+
+		if m := reg.Get("GET /missing").Metrics(); m.TotalSuccesses != 10 {
+			t.Fatalf("TotalSuccesses = %d, want 10", m.TotalSuccesses)
+		}
+	})
+
+	t.Run("panics are recorded as failures and propagated", func(t *testing.T) {
+		t.Parallel()
+		reg := newRegistry()
+		h := Middleware(reg, MethodPathKey)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panic("handler exploded")
+		}))
+
+		for i := 0; i < 5; i++ {
+			func() {
+				defer func() {
+					if v := recover(); v != "handler exploded" {
+						t.Fatalf("recovered %v, want handler panic", v)
+					}
+				}()
+				serve(h, "/panic")
+			}()
+		}
+
+		if reg.Get("GET /panic").State() != circuitbreaker.StateOpen {
+			t.Fatal("breaker should be Open after panics")
+		}
+	})
+
+	t.Run("streaming handlers can flush and hijack", func(t *testing.T) {
+		t.Parallel()
+		reg := newRegistry()
+		h := Middleware(reg, MethodPathKey)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/hijack" {
+				conn, buf, err := w.(http.Hijacker).Hijack()
+				if err != nil {
+					t.Errorf("Hijack: %v", err)
+					return
+				}
+				defer conn.Close()
+				buf.WriteString("HTTP/1.1 204 No Content\r\n\r\n")
+				buf.Flush()
+				return
+			}
+			w.Write([]byte("event: tick\n\n"))
+			w.(http.Flusher).Flush()
+		}))
+
+		if rec := serve(h, "/events"); !rec.Flushed {
+			t.Fatal("response was not flushed")
+		}
+
+		srv := httptest.NewServer(h)
+		defer srv.Close()
+		resp, err := http.Get(srv.URL + "/hijack")
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNoContent {
+			t.Fatalf("status = %d, want 204 from the hijacked connection", resp.StatusCode)
+		}
+	})
+
+	t.Run("panics are failures whatever the error classification", func(t *testing.T) {
+		t.Parallel()
+		errDB := errors.New("db")
+		reg := circuitbreaker.NewRegistry(circuitbreaker.Config{
+			WindowSize:   5,
+			MinRequests:  5,
+			RecordErrors: []error{errDB},
+		})
+		h := Middleware(reg, MethodPathKey)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panic("handler exploded")
+		}))
+
+		for i := 0; i < 5; i++ {
+			func() {
+				defer func() { recover() }()
+				serve(h, "/panic")
+			}()
+		}
+
+		m := reg.Get("GET /panic").Metrics()
+		if m.TotalFailures != 5 || m.TotalSuccesses != 0 || m.CurrentState != circuitbreaker.StateOpen {
+			t.Fatalf("metrics = %+v, want 5 failures and Open", m)
+		}
+	})
+}
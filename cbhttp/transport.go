@@ -0,0 +1,120 @@
+// Package cbhttp integrates circuit breakers with net/http.
+package cbhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	circuitbreaker "github.com/awasame/circuitbreaker"
+)
+
+// Transport is an http.RoundTripper that sends every request through a
+// circuit breaker taken from Registry. Transport errors and responses
+// with a 5xx status are reported to the breaker as errors, so they are
+// classified by the breaker's Config (IsFailure, IgnoreErrors,
+// RecordErrors); 5xx responses are still returned to the caller.
+//
+// When the breaker rejects a request, the request is not sent and
+// RoundTrip returns an *OpenError, unless the breaker's Fallback returns
+// an *http.Response. A request whose context is done while it waits for
+// a bulkhead slot fails with the context's error, not an *OpenError.
+type Transport struct {
+	// Base is the RoundTripper used to send requests.
+	// Default: http.DefaultTransport.
+	Base http.RoundTripper
+
+	// Registry supplies the breaker for each request. Required.
+	Registry *circuitbreaker.Registry
+
+	// Key returns the name of the breaker a request goes through.
+	// Default: HostKey.
+	Key func(req *http.Request) string
+}
+
+// HostKey returns the request's URL host (including any port), giving
+// one breaker per downstream host.
+func HostKey(req *http.Request) string {
+	return req.URL.Host
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := HostKey
+	if t.Key != nil {
+		key = t.Key
+	}
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	name := key(req)
+	sent := false
+	result, err := t.Registry.Get(name).Execute(req.Context(), func(_ context.Context) (any, error) {
+		sent = true
+		resp, err := base.RoundTrip(req)
+		if err == nil && resp.StatusCode >= 500 {
+			return resp, &StatusError{StatusCode: resp.StatusCode}
+		}
+		return resp, err
+	})
+
+	resp, _ := result.(*http.Response)
+	if !sent {
+		// A RoundTripper must close the body even if it sends nothing.
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		if err != nil && err == req.Context().Err() {
+			// The caller gave up while queued for a bulkhead slot.
+			return nil, err
+		}
+		// Rejected by the breaker; the fallback may have supplied a response.
+		if err != nil {
+			return nil, &OpenError{Key: name, Err: err}
+		}
+		if resp == nil {
+			return nil, &OpenError{Key: name, Err: circuitbreaker.ErrCircuitOpen}
+		}
+		return resp, nil
+	}
+
+	var se *StatusError
+	if errors.As(err, &se) {
+		return resp, nil
+	}
+	return resp, err
+}
+
+// OpenError is returned by Transport when the breaker for a request
+// rejects it. It wraps the breaker's error, so
+// errors.Is(err, circuitbreaker.ErrCircuitOpen) reports true for requests
+// rejected by an Open breaker.
+type OpenError struct {
+	// Key is the name of the breaker that rejected the request.
+	Key string
+
+	// Err is the rejection error returned by the breaker.
+	Err error
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("cbhttp: request rejected by circuit breaker %q: %v", e.Key, e.Err)
+}
+
+func (e *OpenError) Unwrap() error {
+	return e.Err
+}
+
+// StatusError is reported to the breaker for a response with a 5xx
+// status, so Config.IsFailure and friends can tell status codes apart.
+// It is never returned to the caller of RoundTrip.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("cbhttp: server responded %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
@@ -0,0 +1,258 @@
+package cbhttp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	circuitbreaker "github.com/awasame/circuitbreaker"
+)
+
+func TestTransport(t *testing.T) {
+	t.Parallel()
+
+	// newServer returns a server that responds with the status stored in
+	// status and counts the requests it receives.
+	newServer := func(t *testing.T, status *atomic.Int32, hits *atomic.Int32) *httptest.Server {
+		t.Helper()
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			hits.Add(1)
+			w.WriteHeader(int(status.Load()))
+		}))
+		t.Cleanup(srv.Close)
+		return srv
+	}
+
+	newRegistry := func() *circuitbreaker.Registry {
+		return circuitbreaker.NewRegistry(circuitbreaker.Config{
+			WindowSize:      5,
+			MinRequests:     5,
+			RecoveryTimeout: time.Minute,
+		})
+	}
+
+	t.Run("5xx responses are returned and trip the breaker", func(t *testing.T) {
+		t.Parallel()
+		var status, hits atomic.Int32
+		status.Store(http.StatusBadGateway)
+		srv := newServer(t, &status, &hits)
+
+		reg := newRegistry()
+		client := &http.Client{Transport: &Transport{Registry: reg}}
+
+		for i := 0; i < 5; i++ {
+			resp, err := client.Get(srv.URL)
+			if err != nil {
+				t.Fatalf("request %d: unexpected error: %v", i, err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusBadGateway {
+				t.Fatalf("status = %d, want 502", resp.StatusCode)
+			}
+		}
+
+		_, err := client.Get(srv.URL)
+		if !errors.Is(err, circuitbreaker.ErrCircuitOpen) {
+			t.Fatalf("err = %v, want ErrCircuitOpen", err)
+		}
+		var oe *OpenError
+		if !errors.As(err, &oe) {
+			t.Fatalf("err = %v, want *OpenError", err)
+		}
+		if want := srv.Listener.Addr().String(); oe.Key != want {
+			t.Fatalf("Key = %q, want %q", oe.Key, want)
+		}
+		if got := hits.Load(); got != 5 {
+			t.Fatalf("server hits = %d, want 5 (rejected request must not be sent)", got)
+		}
+	})
+
+	t.Run("4xx responses are successes", func(t *testing.T) {
+		t.Parallel()
+		var status, hits atomic.Int32
+		status.Store(http.StatusNotFound)
+		srv := newServer(t, &status, &hits)
+
+		reg := newRegistry()
+		client := &http.Client{Transport: &Transport{Registry: reg}}
+
+		for i := 0; i < 10; i++ {
+			resp, err := client.Get(srv.URL)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			resp.Body.Close()
+		}
+
+		cb := reg.Get(srv.Listener.Addr().String())
+		if cb.State() != circuitbreaker.StateClosed {
+			t.Fatalf("state = %v, want Closed", cb.State())
+		}
+		if m := cb.Metrics(); m.TotalSuccesses != 10 {
+			t.Fatalf("TotalSuccesses = %d, want 10", m.TotalSuccesses)
+		}
+	})
+
+	t.Run("status errors go through the breaker's classification", func(t *testing.T) {
+		t.Parallel()
+		var status, hits atomic.Int32
+		status.Store(http.StatusServiceUnavailable)
+		srv := newServer(t, &status, &hits)
+
+		reg := circuitbreaker.NewRegistry(circuitbreaker.Config{
+			WindowSize:  5,
+			MinRequests: 5,
+			IsFailure: func(err error) bool {
+				var se *StatusError
+				return !errors.As(err, &se) || se.StatusCode != http.StatusServiceUnavailable
+			},
+		})
+		client := &http.Client{Transport: &Transport{Registry: reg}}
+
+		for i := 0; i < 10; i++ {
+			resp, err := client.Get(srv.URL)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			resp.Body.Close()
+		}
+
+		if got := hits.Load(); got != 10 {
+			t.Fatalf("server hits = %d, want 10", got)
+		}
+	})
+
+	t.Run("transport errors are failures", func(t *testing.T) {
+		t.Parallel()
+		reg := newRegistry()
+		base := roundTripFunc(func(*http.Request) (*http.Response, error) {
+			return nil, errors.New("connection refused")
+		})
+		client := &http.Client{Transport: &Transport{Base: base, Registry: reg}}
+
+		for i := 0; i < 5; i++ {
+			if _, err := client.Get("http://downstream.invalid/"); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+		}
+
+		if _, err := client.Get("http://downstream.invalid/"); !errors.Is(err, circuitbreaker.ErrCircuitOpen) {
+			t.Fatalf("err = %v, want ErrCircuitOpen", err)
+		}
+	})
+
+	t.Run("Key selects the breaker", func(t *testing.T) {
+		t.Parallel()
+		reg := newRegistry()
+		base := roundTripFunc(func(*http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+		})
+		tr := &Transport{
+			Base:     base,
+			Registry: reg,
+			Key:      func(req *http.Request) string { return req.Method + " " + req.URL.Path },
+		}
+
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://example.test/users", nil)
+		if _, err := tr.RoundTrip(req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, ok := reg.All()["GET /users"]; !ok {
+			t.Fatalf("breakers = %v, want GET /users", reg.All())
+		}
+	})
+
+	t.Run("Fallback response is returned when open", func(t *testing.T) {
+		t.Parallel()
+		reg := circuitbreaker.NewRegistry(circuitbreaker.Config{
+			Fallback: func(context.Context, error) (any, error) {
+				return &http.Response{StatusCode: http.StatusTeapot, Body: http.NoBody}, nil
+			},
+		})
+		reg.Get("example.test").ForceOpen()
+
+		tr := &Transport{Base: roundTripFunc(func(*http.Request) (*http.Response, error) {
+			t.Fatal("request must not be sent")
+			return nil, nil
+		}), Registry: reg}
+
+		req, _ := http.NewRequest(http.MethodGet, "http://example.test/", nil)
+		resp, err := tr.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.StatusCode != http.StatusTeapot {
+			t.Fatalf("status = %d, want 418", resp.StatusCode)
+		}
+	})
+
+	t.Run("request body is closed when rejected", func(t *testing.T) {
+		t.Parallel()
+		reg := circuitbreaker.NewRegistry(circuitbreaker.Config{})
+		reg.Get("example.test").ForceOpen()
+		tr := &Transport{Base: roundTripFunc(func(*http.Request) (*http.Response, error) {
+			t.Fatal("request must not be sent")
+			return nil, nil
+		}), Registry: reg}
+
+		body := &closeRecorder{Reader: strings.NewReader("payload")}
+		req, _ := http.NewRequest(http.MethodPost, "http://example.test/", body)
+		if _, err := tr.RoundTrip(req); !errors.Is(err, circuitbreaker.ErrCircuitOpen) {
+			t.Fatalf("err = %v, want ErrCircuitOpen", err)
+		}
+		if !body.closed {
+			t.Fatal("request body was not closed")
+		}
+	})
+
+	t.Run("context expiring in the bulkhead queue is not an OpenError", func(t *testing.T) {
+		t.Parallel()
+		reg := circuitbreaker.NewRegistry(circuitbreaker.Config{MaxConcurrent: 1, MaxQueue: 1})
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		defer close(release)
+		tr := &Transport{Base: roundTripFunc(func(*http.Request) (*http.Response, error) {
+			close(started)
+			<-release
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+		}), Registry: reg}
+
+		go func() {
+			req, _ := http.NewRequest(http.MethodGet, "http://example.test/", nil)
+			tr.RoundTrip(req)
+		}()
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.test/", nil)
+		_, err := tr.RoundTrip(req)
+		var oe *OpenError
+		if !errors.Is(err, context.DeadlineExceeded) || errors.As(err, &oe) {
+			t.Fatalf("err = %v, want the bare context error", err)
+		}
+	})
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// closeRecorder is a request body that records whether it was closed.
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
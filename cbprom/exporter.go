@@ -0,0 +1,182 @@
+// Package cbprom exports circuit breaker metrics in the Prometheus text
+// exposition format, without depending on the Prometheus client library.
+package cbprom
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	circuitbreaker "github.com/awasame/circuitbreaker"
+)
+
+// contentType is the media type of the Prometheus text format.
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// metric describes one exported metric family.
+type metric struct {
+	name  string
+	help  string
+	typ   string
+	value func(m circuitbreaker.Metrics) float64
+}
+
+var metrics = []metric{
+	{
+		name:  "circuitbreaker_requests_total",
+		help:  "Calls made through the circuit breaker, including rejected ones.",
+		typ:   "counter",
+		value: func(m circuitbreaker.Metrics) float64 { return float64(m.TotalRequests) },
+	},
+	{
+		name:  "circuitbreaker_successes_total",
+		help:  "Calls recorded as successes.",
+		typ:   "counter",
+		value: func(m circuitbreaker.Metrics) float64 { return float64(m.TotalSuccesses) },
+	},
+	{
+		name:  "circuitbreaker_failures_total",
+		help:  "Calls recorded as failures.",
+		typ:   "counter",
+		value: func(m circuitbreaker.Metrics) float64 { return float64(m.TotalFailures) },
+	},
+	{
+		name:  "circuitbreaker_rejections_total",
+		help:  "Calls rejected by the circuit breaker without being executed.",
+		typ:   "counter",
+		value: func(m circuitbreaker.Metrics) float64 { return float64(m.TotalRejections) },
+	},
+	{
+		name:  "circuitbreaker_fallbacks_total",
+		help:  "Rejected calls served by the fallback.",
+		typ:   "counter",
+		value: func(m circuitbreaker.Metrics) float64 { return float64(m.TotalFallbacks) },
+	},
+	{
+		name:  "circuitbreaker_ignored_total",
+		help:  "Calls whose outcome was not recorded (cancelled or ignored errors).",
+		typ:   "counter",
+		value: func(m circuitbreaker.Metrics) float64 { return float64(m.TotalIgnored) },
+	},
+	{
+		name:  "circuitbreaker_probe_successes_total",
+		help:  "Half-Open probe calls recorded as successes.",
+		typ:   "counter",
+		value: func(m circuitbreaker.Metrics) float64 { return float64(m.ProbeSuccesses) },
+	},
+	{
+		name:  "circuitbreaker_probe_failures_total",
+		help:  "Half-Open probe calls recorded as failures or slow calls.",
+		typ:   "counter",
+		value: func(m circuitbreaker.Metrics) float64 { return float64(m.ProbeFailures) },
+	},
+	{
+		name:  "circuitbreaker_window_failure_rate",
+		help:  "Ratio of failures in the sliding window.",
+		typ:   "gauge",
+		value: func(m circuitbreaker.Metrics) float64 { return m.WindowFailureRate },
+	},
+	{
+		name:  "circuitbreaker_window_slow_call_rate",
+		help:  "Ratio of slow calls in the sliding window.",
+		typ:   "gauge",
+		value: func(m circuitbreaker.Metrics) float64 { return m.WindowSlowRate },
+	},
+	{
+		name:  "circuitbreaker_reject_probability",
+		help:  "Probability that the next call is throttled, in throttle mode.",
+		typ:   "gauge",
+		value: func(m circuitbreaker.Metrics) float64 { return m.RejectProbability },
+	},
+	{
+		name:  "circuitbreaker_in_flight",
+		help:  "Calls currently running through the circuit breaker.",
+		typ:   "gauge",
+		value: func(m circuitbreaker.Metrics) float64 { return float64(m.InFlight) },
+	},
+	{
+		name:  "circuitbreaker_queued",
+		help:  "Calls waiting for a bulkhead slot.",
+		typ:   "gauge",
+		value: func(m circuitbreaker.Metrics) float64 { return float64(m.Queued) },
+	},
+}
+
+// states lists every state reported by the circuitbreaker_state gauge.
+var states = []circuitbreaker.State{
+	circuitbreaker.StateClosed,
+	circuitbreaker.StateOpen,
+	circuitbreaker.StateHalfOpen,
+	circuitbreaker.StateForcedOpen,
+	circuitbreaker.StateDisabled,
+	circuitbreaker.StateRecovering,
+}
+
+// Handler returns an http.Handler that serves the metrics of every
+// breaker in reg in the Prometheus text exposition format.
+func Handler(reg *circuitbreaker.Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		WriteMetrics(w, reg)
+	})
+}
+
+// WriteMetrics writes the metrics of every breaker in reg to w in the
+// Prometheus text exposition format. Breakers are ordered by name.
+//
+// The circuitbreaker_state gauge has one series per breaker and state,
+// set to 1 for the breaker's current state and 0 otherwise.
+func WriteMetrics(w io.Writer, reg *circuitbreaker.Registry) error {
+	all := reg.All()
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	snapshots := make([]circuitbreaker.Metrics, len(names))
+	for i, name := range names {
+		snapshots[i] = all[name].Metrics()
+	}
+
+	bw := bufio.NewWriter(w)
+
+	for _, m := range metrics {
+		writeHeader(bw, m.name, m.help, m.typ)
+		for i, name := range names {
+			writeSample(bw, m.name, `name="`+escape(name)+`"`, m.value(snapshots[i]))
+		}
+	}
+
+	writeHeader(bw, "circuitbreaker_state", "Current state of the circuit breaker (1 for the current state).", "gauge")
+	for i, name := range names {
+		for _, st := range states {
+			v := 0.0
+			if snapshots[i].CurrentState == st {
+				v = 1
+			}
+			writeSample(bw, "circuitbreaker_state", `name="`+escape(name)+`",state="`+st.String()+`"`, v)
+		}
+	}
+
+	return bw.Flush()
+}
+
+func writeHeader(w *bufio.Writer, name, help, typ string) {
+	w.WriteString("# HELP " + name + " " + help + "\n")
+	w.WriteString("# TYPE " + name + " " + typ + "\n")
+}
+
+func writeSample(w *bufio.Writer, name, labels string, v float64) {
+	w.WriteString(name + "{" + labels + "} " + strconv.FormatFloat(v, 'g', -1, 64) + "\n")
+}
+
+// labelEscaper escapes a label value as required by the text format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escape(s string) string {
+	return labelEscaper.Replace(s)
+}
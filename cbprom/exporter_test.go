@@ -0,0 +1,69 @@
+package cbprom
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	circuitbreaker "github.com/awasame/circuitbreaker"
+)
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	reg := circuitbreaker.NewRegistry(circuitbreaker.Config{WindowSize: 5, MinRequests: 5})
+
+	ok := func(context.Context) (any, error) { return nil, nil }
+	fail := func(context.Context) (any, error) { return nil, errors.New("boom") }
+
+	for i := 0; i < 3; i++ {
+		reg.Get("payments").Execute(context.Background(), ok)
+	}
+	for i := 0; i < 5; i++ {
+		reg.Get(`odd"name`).Execute(context.Background(), fail)
+	}
+	reg.Get(`odd"name`).Execute(context.Background(), ok) // rejected
+
+	rec := httptest.NewRecorder()
+	Handler(reg).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if got := rec.Header().Get("Content-Type"); got != contentType {
+		t.Errorf("Content-Type = %q, want %q", got, contentType)
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE circuitbreaker_requests_total counter\n",
+		`circuitbreaker_requests_total{name="payments"} 3` + "\n",
+		`circuitbreaker_successes_total{name="payments"} 3` + "\n",
+		`circuitbreaker_requests_total{name="odd\"name"} 6` + "\n",
+		`circuitbreaker_rejections_total{name="odd\"name"} 1` + "\n",
+		`circuitbreaker_failures_total{name="odd\"name"} 5` + "\n",
+		"# TYPE circuitbreaker_probe_failures_total counter\n",
+		`circuitbreaker_window_failure_rate{name="odd\"name"} 1` + "\n",
+		"# TYPE circuitbreaker_state gauge\n",
+		`circuitbreaker_state{name="payments",state="closed"} 1` + "\n",
+		`circuitbreaker_state{name="payments",state="open"} 0` + "\n",
+		`circuitbreaker_state{name="odd\"name",state="open"} 1` + "\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("output missing %q\n%s", want, body)
+		}
+	}
+
+	// Breakers are sorted by name, so `odd"name` comes first.
+	if strings.Index(body, `name="odd\"name"`) > strings.Index(body, `name="payments"`) {
+		t.Errorf("breakers not sorted by name:\n%s", body)
+	}
+}
+
+func TestEscape(t *testing.T) {
+	t.Parallel()
+
+	if got, want := escape("a\\b\"c\nd"), `a\\b\"c\nd`; got != want {
+		t.Errorf("escape() = %q, want %q", got, want)
+	}
+}
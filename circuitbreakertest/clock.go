@@ -0,0 +1,135 @@
+// Package circuitbreakertest provides utilities for testing code that
+// uses circuit breakers.
+package circuitbreakertest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	circuitbreaker "github.com/awasame/circuitbreaker"
+)
+
+// FakeClock is a circuitbreaker.Clock whose time only moves when Advance
+// or Set is called. Timers created with AfterFunc run synchronously, in
+// order of their due time, from the Advance or Set call that reaches them.
+//
+//	clock := circuitbreakertest.NewFakeClock(time.Now())
+//	breaker := circuitbreaker.New(circuitbreaker.Config{Clock: clock})
+//	// ... trip the breaker ...
+//	clock.Advance(30 * time.Second) // RecoveryTimeout elapsed, no sleeping
+//
+// A FakeClock is safe for concurrent use.
+type FakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	seq    uint64 // creation order, breaks ties between equal due times
+	timers []*fakeTimer
+}
+
+var _ circuitbreaker.Clock = (*FakeClock)(nil)
+
+// NewFakeClock returns a FakeClock set to t.
+func NewFakeClock(t time.Time) *FakeClock {
+	return &FakeClock{now: t}
+}
+
+// Now returns the clock's current time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// AfterFunc schedules f to run once the clock has been advanced by d.
+// Timers with d <= 0 run on the next call to Advance or Set.
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) circuitbreaker.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.seq++
+	t := &fakeTimer{clock: c, when: c.now.Add(d), seq: c.seq, f: f}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance moves the clock forward by d and runs every timer that has
+// become due, including timers scheduled by those timers. While a timer
+// runs, Now reports its due time.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	target := c.now.Add(d)
+	c.mu.Unlock()
+
+	c.advanceTo(target)
+}
+
+// Set moves the clock to t and runs every timer that has become due, as
+// Advance does.
+func (c *FakeClock) Set(t time.Time) {
+	c.advanceTo(t)
+}
+
+// PendingTimers returns the number of timers that have neither run nor
+// been stopped.
+func (c *FakeClock) PendingTimers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// advanceTo steps the clock through the due times of pending timers up
+// to target, running each timer without holding the lock so that timer
+// functions may use the clock, and finally sets the clock to target.
+func (c *FakeClock) advanceTo(target time.Time) {
+	for {
+		c.mu.Lock()
+		sort.Slice(c.timers, func(i, j int) bool {
+			a, b := c.timers[i], c.timers[j]
+			if a.when.Equal(b.when) {
+				return a.seq < b.seq
+			}
+			return a.when.Before(b.when)
+		})
+		if len(c.timers) == 0 || c.timers[0].when.After(target) {
+			c.now = target
+			c.mu.Unlock()
+			return
+		}
+		t := c.timers[0]
+		c.timers = c.timers[1:]
+		if t.when.After(c.now) {
+			c.now = t.when
+		}
+		c.mu.Unlock()
+
+		t.f()
+	}
+}
+
+// remove deletes t from the pending timers. The caller holds c.mu.
+func (c *FakeClock) remove(t *fakeTimer) bool {
+	for i, x := range c.timers {
+		if x == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// fakeTimer is a timer scheduled on a FakeClock.
+type fakeTimer struct {
+	clock *FakeClock
+	when  time.Time
+	seq   uint64
+	f     func()
+}
+
+// Stop cancels the timer. It returns false if the timer already ran or
+// was stopped.
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.clock.remove(t)
+}
@@ -0,0 +1,110 @@
+package circuitbreakertest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	circuitbreaker "github.com/awasame/circuitbreaker"
+)
+
+func TestFakeClock(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Advance moves Now", func(t *testing.T) {
+		t.Parallel()
+		c := NewFakeClock(start)
+
+		c.Advance(90 * time.Second)
+
+		if got, want := c.Now(), start.Add(90*time.Second); !got.Equal(want) {
+			t.Fatalf("Now() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("timers fire in due order once reached", func(t *testing.T) {
+		t.Parallel()
+		c := NewFakeClock(start)
+
+		var fired []string
+		c.AfterFunc(3*time.Second, func() { fired = append(fired, "3s") })
+		c.AfterFunc(time.Second, func() { fired = append(fired, "1s") })
+		c.AfterFunc(time.Second, func() { fired = append(fired, "1s-b") })
+
+		c.Advance(2 * time.Second)
+		if len(fired) != 2 || fired[0] != "1s" || fired[1] != "1s-b" {
+			t.Fatalf("fired = %v, want [1s 1s-b]", fired)
+		}
+		if got := c.PendingTimers(); got != 1 {
+			t.Fatalf("PendingTimers() = %d, want 1", got)
+		}
+
+		c.Set(start.Add(time.Hour))
+		if len(fired) != 3 || fired[2] != "3s" {
+			t.Fatalf("fired = %v, want [1s 1s-b 3s]", fired)
+		}
+	})
+
+	t.Run("timers may schedule timers", func(t *testing.T) {
+		t.Parallel()
+		c := NewFakeClock(start)
+
+		n := 0
+		var tick func()
+		tick = func() {
+			n++
+			c.AfterFunc(time.Second, tick)
+		}
+		c.AfterFunc(time.Second, tick)
+
+		c.Advance(5 * time.Second)
+		if n != 5 {
+			t.Fatalf("ticks = %d, want 5", n)
+		}
+	})
+
+	t.Run("stopped timers do not fire", func(t *testing.T) {
+		t.Parallel()
+		c := NewFakeClock(start)
+
+		tm := c.AfterFunc(time.Second, func() { t.Error("stopped timer fired") })
+		if !tm.Stop() {
+			t.Fatal("Stop() = false, want true")
+		}
+		if tm.Stop() {
+			t.Fatal("second Stop() = true, want false")
+		}
+		c.Advance(time.Minute)
+	})
+
+	t.Run("drives a breaker's recovery timeout", func(t *testing.T) {
+		t.Parallel()
+		c := NewFakeClock(start)
+		cb := circuitbreaker.New(circuitbreaker.Config{
+			WindowSize:      5,
+			MinRequests:     5,
+			RecoveryTimeout: 30 * time.Second,
+			Clock:           c,
+		})
+
+		for i := 0; i < 5; i++ {
+			cb.Execute(context.Background(), func(context.Context) (any, error) {
+				return nil, errors.New("boom")
+			})
+		}
+		if cb.State() != circuitbreaker.StateOpen {
+			t.Fatalf("state = %v, want Open", cb.State())
+		}
+
+		c.Advance(30 * time.Second)
+		if cb.State() != circuitbreaker.StateHalfOpen {
+			t.Fatalf("state = %v, want HalfOpen", cb.State())
+		}
+		if got := cb.Metrics().LastStateChange; !got.Equal(start.Add(30 * time.Second)) {
+			t.Fatalf("LastStateChange = %v, want %v", got, start.Add(30*time.Second))
+		}
+	})
+}
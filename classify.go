@@ -0,0 +1,86 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"reflect"
+)
+
+// verdict is how the outcome of a call is recorded in the window.
+type verdict int
+
+const (
+	verdictSuccess verdict = iota
+	verdictFailure
+	verdictIgnore
+)
+
+// classify decides how err, as returned by the wrapped function, is
+// recorded. A recovered panic is always a failure. Otherwise the checks
+// run in order: IgnoreErrors, then IsFailure if set, then RecordErrors if
+// non-empty. Without any of them every non-nil error is a failure.
+func (c *Config) classify(err error) verdict {
+	if err == nil {
+		return verdictSuccess
+	}
+	if _, ok := err.(*PanicError); ok {
+		return verdictFailure
+	}
+	if matchesAny(err, c.IgnoreErrors) {
+		return verdictIgnore
+	}
+	if c.IsFailure != nil {
+		if c.IsFailure(err) {
+			return verdictFailure
+		}
+		return verdictSuccess
+	}
+	if len(c.RecordErrors) > 0 && !matchesAny(err, c.RecordErrors) {
+		return verdictSuccess
+	}
+	return verdictFailure
+}
+
+// errorMatcher is implemented by list entries that match by something
+// other than errors.Is.
+type errorMatcher interface {
+	matchError(err error) bool
+}
+
+// matchesAny reports whether err matches any of targets. Entries created
+// by ErrorType match with errors.As; all others with errors.Is.
+func matchesAny(err error, targets []error) bool {
+	for _, target := range targets {
+		if m, ok := target.(errorMatcher); ok {
+			if m.matchError(err) {
+				return true
+			}
+			continue
+		}
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrorType returns an entry for Config.IgnoreErrors or Config.RecordErrors
+// that matches any error whose chain contains an error of type T, as
+// reported by errors.As.
+//
+//	IgnoreErrors: []error{cb.ErrorType[*ValidationError]()}
+func ErrorType[T error]() error {
+	return typeMatcher[T]{}
+}
+
+// typeMatcher matches errors by type. It only exists to be placed in an
+// error list and is never returned from a call.
+type typeMatcher[T error] struct{}
+
+func (typeMatcher[T]) Error() string {
+	return "error of type " + reflect.TypeOf((*T)(nil)).Elem().String()
+}
+
+func (typeMatcher[T]) matchError(err error) bool {
+	var target T
+	return errors.As(err, &target)
+}
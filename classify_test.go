@@ -0,0 +1,152 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type statusError struct{ code int }
+
+func (e *statusError) Error() string { return fmt.Sprintf("status %d", e.code) }
+
+var (
+	errNotFound   = errors.New("not found")
+	errValidation = errors.New("validation failed")
+)
+
+func TestClassify(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		cfg  Config
+		err  error
+		want verdict
+	}{
+		{"nil error is a success", Config{}, nil, verdictSuccess},
+		{"any error is a failure by default", Config{}, errBoom, verdictFailure},
+		{
+			"ignored by errors.Is",
+			Config{IgnoreErrors: []error{errNotFound}},
+			fmt.Errorf("get user: %w", errNotFound),
+			verdictIgnore,
+		},
+		{
+			"ignored by errors.As",
+			Config{IgnoreErrors: []error{ErrorType[*statusError]()}},
+			fmt.Errorf("call: %w", &statusError{404}),
+			verdictIgnore,
+		},
+		{
+			"unmatched ignore list still fails",
+			Config{IgnoreErrors: []error{errNotFound, ErrorType[*statusError]()}},
+			errBoom,
+			verdictFailure,
+		},
+		{
+			"IsFailure false records a success",
+			Config{IsFailure: func(err error) bool { return !errors.Is(err, errValidation) }},
+			errValidation,
+			verdictSuccess,
+		},
+		{
+			"IsFailure true records a failure",
+			Config{IsFailure: func(err error) bool { return !errors.Is(err, errValidation) }},
+			errBoom,
+			verdictFailure,
+		},
+		{
+			"ignore list takes precedence over IsFailure",
+			Config{
+				IgnoreErrors: []error{errNotFound},
+				IsFailure:    func(error) bool { return true },
+			},
+			errNotFound,
+			verdictIgnore,
+		},
+		{
+			"record list match is a failure",
+			Config{RecordErrors: []error{errBoom}},
+			fmt.Errorf("wrapped: %w", errBoom),
+			verdictFailure,
+		},
+		{
+			"record list miss is a success",
+			Config{RecordErrors: []error{errBoom}},
+			errValidation,
+			verdictSuccess,
+		},
+		{
+			"IsFailure takes precedence over record list",
+			Config{
+				RecordErrors: []error{errBoom},
+				IsFailure:    func(error) bool { return true },
+			},
+			errValidation,
+			verdictFailure,
+		},
+	}
+
+	for _, tt := range tests {
+		if got := tt.cfg.classify(tt.err); got != tt.want {
+			t.Errorf("%s: classify(%v) = %v, want %v", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestCircuitBreakerClassification(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ignored errors do not enter the window", func(t *testing.T) {
+		t.Parallel()
+		cb, _ := newTestBreaker(Config{
+			Name:         "test",
+			MinRequests:  5,
+			IgnoreErrors: []error{errNotFound},
+		})
+
+		for i := 0; i < 10; i++ {
+			_, err := cb.Execute(context.Background(), func(context.Context) (any, error) {
+				return nil, errNotFound
+			})
+			if !errors.Is(err, errNotFound) {
+				t.Fatalf("err = %v, want errNotFound", err)
+			}
+		}
+
+		if cb.State() != StateClosed {
+			t.Fatalf("state = %v, want Closed", cb.State())
+		}
+		m := cb.Metrics()
+		if m.TotalFailures != 0 || m.TotalSuccesses != 0 || m.WindowFailureRate != 0 {
+			t.Fatalf("metrics = %+v, want no recorded outcomes", m)
+		}
+	})
+
+	t.Run("errors classified as successes do not trip", func(t *testing.T) {
+		t.Parallel()
+		cb, _ := newTestBreaker(Config{
+			Name:        "test",
+			MinRequests: 5,
+			IsFailure: func(err error) bool {
+				var se *statusError
+				return !errors.As(err, &se) || se.code >= 500
+			},
+		})
+
+		for i := 0; i < 10; i++ {
+			cb.Execute(context.Background(), func(context.Context) (any, error) {
+				return nil, &statusError{404}
+			})
+		}
+
+		if cb.State() != StateClosed {
+			t.Fatalf("state = %v, want Closed", cb.State())
+		}
+		if m := cb.Metrics(); m.TotalSuccesses != 10 {
+			t.Fatalf("TotalSuccesses = %d, want 10", m.TotalSuccesses)
+		}
+	})
+}
@@ -0,0 +1,30 @@
+package circuitbreaker
+
+import "time"
+
+// Clock tells the time and schedules timers for a circuit breaker.
+// Replace it in Config to drive breakers deterministically in tests; the
+// circuitbreakertest package provides a manually advanced implementation.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// AfterFunc calls f in its own goroutine once d has elapsed.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a pending call scheduled by Clock.AfterFunc.
+type Timer interface {
+	// Stop prevents the call from running. It returns false if the call
+	// has already run or been stopped.
+	Stop() bool
+}
+
+// systemClock is the Clock backed by the time package.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
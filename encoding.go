@@ -0,0 +1,194 @@
+package circuitbreaker
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// configJSON is the JSON form of Config. Function, error list,
+// TripStrategy, Logger and Clock fields have no JSON form and are left
+// out.
+type configJSON struct {
+	Name                string         `json:"name,omitempty"`
+	WindowType          WindowType     `json:"window_type,omitempty"`
+	WindowSize          int            `json:"window_size,omitempty"`
+	WindowDuration      duration       `json:"window_duration,omitempty"`
+	WindowBuckets       int            `json:"window_buckets,omitempty"`
+	FailureThreshold    float64        `json:"failure_threshold,omitempty"`
+	SlowCallDuration    duration       `json:"slow_call_duration,omitempty"`
+	SlowCallThreshold   float64        `json:"slow_call_threshold,omitempty"`
+	MinRequests         int            `json:"min_requests,omitempty"`
+	Mode                Mode           `json:"mode,omitempty"`
+	ThrottleK           float64        `json:"throttle_k,omitempty"`
+	RecoveryTimeout     duration       `json:"recovery_timeout,omitempty"`
+	AutoHalfOpen        bool           `json:"auto_half_open,omitempty"`
+	RecoveryBackoff     *BackoffPolicy `json:"recovery_backoff,omitempty"`
+	ProbeCount          int            `json:"probe_count,omitempty"`
+	MaxConcurrentProbes int            `json:"max_concurrent_probes,omitempty"`
+	RampUpDuration      duration       `json:"ramp_up_duration,omitempty"`
+	RampUpCurve         RampCurve      `json:"ramp_up_curve,omitempty"`
+	MaxConcurrent       int            `json:"max_concurrent,omitempty"`
+	MaxQueue            int            `json:"max_queue,omitempty"`
+	QueueTimeout        duration       `json:"queue_timeout,omitempty"`
+	RecoverPanics       bool           `json:"recover_panics,omitempty"`
+}
+
+// MarshalJSON encodes the fields of c that have a JSON form, using
+// snake_case keys. Durations are encoded as strings such as "30s", and
+// WindowType as "count-based" or "time-based". Zero fields are omitted.
+func (c Config) MarshalJSON() ([]byte, error) {
+	j := configJSON{
+		Name:                c.Name,
+		WindowType:          c.WindowType,
+		WindowSize:          c.WindowSize,
+		WindowDuration:      duration(c.WindowDuration),
+		WindowBuckets:       c.WindowBuckets,
+		FailureThreshold:    c.FailureThreshold,
+		SlowCallDuration:    duration(c.SlowCallDuration),
+		SlowCallThreshold:   c.SlowCallThreshold,
+		MinRequests:         c.MinRequests,
+		Mode:                c.Mode,
+		ThrottleK:           c.ThrottleK,
+		RecoveryTimeout:     duration(c.RecoveryTimeout),
+		AutoHalfOpen:        c.AutoHalfOpen,
+		ProbeCount:          c.ProbeCount,
+		MaxConcurrentProbes: c.MaxConcurrentProbes,
+		RampUpDuration:      duration(c.RampUpDuration),
+		RampUpCurve:         c.RampUpCurve,
+		MaxConcurrent:       c.MaxConcurrent,
+		MaxQueue:            c.MaxQueue,
+		QueueTimeout:        duration(c.QueueTimeout),
+		RecoverPanics:       c.RecoverPanics,
+	}
+	if c.RecoveryBackoff != (BackoffPolicy{}) {
+		j.RecoveryBackoff = &c.RecoveryBackoff
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON decodes the form produced by MarshalJSON over the current
+// value of c: fields missing from data, and fields with no JSON form such
+// as IsFailure, TripStrategy or Logger, keep their values. Unknown keys are an error.
+// The result is not validated; see Validate.
+func (c *Config) UnmarshalJSON(data []byte) error {
+	backoff := c.RecoveryBackoff
+	j := configJSON{
+		Name:                c.Name,
+		WindowType:          c.WindowType,
+		WindowSize:          c.WindowSize,
+		WindowDuration:      duration(c.WindowDuration),
+		WindowBuckets:       c.WindowBuckets,
+		FailureThreshold:    c.FailureThreshold,
+		SlowCallDuration:    duration(c.SlowCallDuration),
+		SlowCallThreshold:   c.SlowCallThreshold,
+		MinRequests:         c.MinRequests,
+		Mode:                c.Mode,
+		ThrottleK:           c.ThrottleK,
+		RecoveryTimeout:     duration(c.RecoveryTimeout),
+		AutoHalfOpen:        c.AutoHalfOpen,
+		RecoveryBackoff:     &backoff,
+		ProbeCount:          c.ProbeCount,
+		MaxConcurrentProbes: c.MaxConcurrentProbes,
+		RampUpDuration:      duration(c.RampUpDuration),
+		RampUpCurve:         c.RampUpCurve,
+		MaxConcurrent:       c.MaxConcurrent,
+		MaxQueue:            c.MaxQueue,
+		QueueTimeout:        duration(c.QueueTimeout),
+		RecoverPanics:       c.RecoverPanics,
+	}
+	if err := decodeStrict(data, &j); err != nil {
+		return err
+	}
+
+	c.Name = j.Name
+	c.WindowType = j.WindowType
+	c.WindowSize = j.WindowSize
+	c.WindowDuration = time.Duration(j.WindowDuration)
+	c.WindowBuckets = j.WindowBuckets
+	c.FailureThreshold = j.FailureThreshold
+	c.SlowCallDuration = time.Duration(j.SlowCallDuration)
+	c.SlowCallThreshold = j.SlowCallThreshold
+	c.MinRequests = j.MinRequests
+	c.Mode = j.Mode
+	c.ThrottleK = j.ThrottleK
+	c.RecoveryTimeout = time.Duration(j.RecoveryTimeout)
+	c.AutoHalfOpen = j.AutoHalfOpen
+	if j.RecoveryBackoff != nil {
+		c.RecoveryBackoff = *j.RecoveryBackoff
+	} else {
+		c.RecoveryBackoff = BackoffPolicy{} // explicit null
+	}
+	c.ProbeCount = j.ProbeCount
+	c.MaxConcurrentProbes = j.MaxConcurrentProbes
+	c.RampUpDuration = time.Duration(j.RampUpDuration)
+	c.RampUpCurve = j.RampUpCurve
+	c.MaxConcurrent = j.MaxConcurrent
+	c.MaxQueue = j.MaxQueue
+	c.QueueTimeout = time.Duration(j.QueueTimeout)
+	c.RecoverPanics = j.RecoverPanics
+	return nil
+}
+
+// backoffJSON is the JSON form of BackoffPolicy.
+type backoffJSON struct {
+	Initial    duration `json:"initial,omitempty"`
+	Multiplier float64  `json:"multiplier,omitempty"`
+	Max        duration `json:"max,omitempty"`
+	Jitter     float64  `json:"jitter,omitempty"`
+}
+
+// MarshalJSON encodes b with durations as strings such as "30s".
+func (b BackoffPolicy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(backoffJSON{
+		Initial:    duration(b.Initial),
+		Multiplier: b.Multiplier,
+		Max:        duration(b.Max),
+		Jitter:     b.Jitter,
+	})
+}
+
+// UnmarshalJSON decodes the form produced by MarshalJSON over the current
+// value of b. Unknown keys are an error.
+func (b *BackoffPolicy) UnmarshalJSON(data []byte) error {
+	j := backoffJSON{
+		Initial:    duration(b.Initial),
+		Multiplier: b.Multiplier,
+		Max:        duration(b.Max),
+		Jitter:     b.Jitter,
+	}
+	if err := decodeStrict(data, &j); err != nil {
+		return err
+	}
+	*b = BackoffPolicy{
+		Initial:    time.Duration(j.Initial),
+		Multiplier: j.Multiplier,
+		Max:        time.Duration(j.Max),
+		Jitter:     j.Jitter,
+	}
+	return nil
+}
+
+// decodeStrict decodes data into v, rejecting unknown keys.
+func decodeStrict(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// duration is a time.Duration encoded as a string such as "1m30s".
+type duration time.Duration
+
+func (d duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return fmt.Errorf("circuit breaker: invalid duration %q", text)
+	}
+	*d = duration(v)
+	return nil
+}
@@ -0,0 +1,109 @@
+package circuitbreaker
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigJSON(t *testing.T) {
+	t.Parallel()
+
+	t.Run("round trip with durations as strings", func(t *testing.T) {
+		t.Parallel()
+		cfg := Config{
+			Name:             "payment-api",
+			WindowType:       WindowTimeBased,
+			WindowDuration:   30 * time.Second,
+			FailureThreshold: 0.25,
+			RecoveryTimeout:  90 * time.Second,
+			RecoveryBackoff:  BackoffPolicy{Multiplier: 2, Max: 10 * time.Minute},
+			AutoHalfOpen:     true,
+		}
+
+		data, err := json.Marshal(cfg)
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+		want := `{"name":"payment-api","window_type":"time-based","window_duration":"30s",` +
+			`"failure_threshold":0.25,"recovery_timeout":"1m30s","auto_half_open":true,` +
+			`"recovery_backoff":{"multiplier":2,"max":"10m0s"}}`
+		if string(data) != want {
+			t.Fatalf("Marshal = %s\nwant      %s", data, want)
+		}
+
+		var got Config
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("Unmarshal: %v", err)
+		}
+		if got.Name != cfg.Name || got.WindowType != cfg.WindowType ||
+			got.WindowDuration != cfg.WindowDuration || got.FailureThreshold != cfg.FailureThreshold ||
+			got.RecoveryTimeout != cfg.RecoveryTimeout || got.RecoveryBackoff != cfg.RecoveryBackoff ||
+			got.AutoHalfOpen != cfg.AutoHalfOpen {
+			t.Fatalf("Unmarshal = %+v, want %+v", got, cfg)
+		}
+	})
+
+	t.Run("unmarshal keeps fields missing from the document", func(t *testing.T) {
+		t.Parallel()
+		isFailure := func(error) bool { return true }
+		cfg := Config{MinRequests: 7, ProbeCount: 2, IsFailure: isFailure}
+
+		if err := json.Unmarshal([]byte(`{"probe_count":4}`), &cfg); err != nil {
+			t.Fatalf("Unmarshal: %v", err)
+		}
+		if cfg.MinRequests != 7 || cfg.ProbeCount != 4 || cfg.IsFailure == nil {
+			t.Fatalf("cfg = %+v, want MinRequests 7, ProbeCount 4 and IsFailure kept", cfg)
+		}
+	})
+
+	t.Run("invalid input is rejected", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			doc  string
+			want string
+		}{
+			{`{"recovery_timeout":"soon"}`, `invalid duration "soon"`},
+			{`{"recovery_timeout":30}`, "recovery_timeout"},
+			{`{"window_type":"sliding"}`, `unknown window type "sliding"`},
+			{`{"ramp_up_curve":"cubic"}`, `unknown ramp curve "cubic"`},
+			{`{"failure_treshold":0.5}`, "failure_treshold"},
+			{`{"recovery_backoff":{"factor":2}}`, "factor"},
+		}
+		for _, tt := range tests {
+			var cfg Config
+			err := json.Unmarshal([]byte(tt.doc), &cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Unmarshal(%s) error = %v, want it to mention %q", tt.doc, err, tt.want)
+			}
+		}
+	})
+}
+
+func TestStateText(t *testing.T) {
+	t.Parallel()
+
+	data, err := json.Marshal(Metrics{CurrentState: StateHalfOpen})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if !strings.Contains(string(data), `"CurrentState":"half-open"`) {
+		t.Fatalf("Marshal = %s, want CurrentState as a name", data)
+	}
+
+	for _, st := range []State{StateClosed, StateOpen, StateHalfOpen, StateForcedOpen, StateDisabled, StateRecovering} {
+		var got State
+		if err := got.UnmarshalText([]byte(st.String())); err != nil || got != st {
+			t.Errorf("UnmarshalText(%q) = %v, %v; want %v", st, got, err, st)
+		}
+	}
+
+	var s State
+	if err := s.UnmarshalText([]byte("ajar")); err == nil {
+		t.Error("UnmarshalText(ajar): want error")
+	}
+	if _, err := State(99).MarshalText(); err == nil {
+		t.Error("MarshalText(99): want error")
+	}
+}
@@ -1,7 +1,49 @@
 package circuitbreaker
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 // ErrCircuitOpen is returned when the circuit breaker is in the Open state
 // and rejects the request without executing the wrapped function.
 var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// ErrTooManyProbes is returned when the circuit breaker is in the
+// Half-Open state and already has MaxConcurrentProbes probe requests in
+// flight.
+var ErrTooManyProbes = errors.New("circuit breaker is half-open: too many probes in flight")
+
+// ErrRampingUp is returned when the circuit breaker is in the Recovering
+// state and sheds the request to limit the load on a service that has
+// just come back.
+var ErrRampingUp = errors.New("circuit breaker is recovering: request shed during ramp-up")
+
+// ErrThrottled is returned in ModeThrottle when the circuit breaker
+// rejects the request locally because the backend has been failing.
+var ErrThrottled = errors.New("circuit breaker is throttling: request rejected locally")
+
+// ErrBulkheadFull is returned when MaxConcurrent calls are already in
+// flight and the request could not wait for a slot, because the queue
+// was full or QueueTimeout expired.
+var ErrBulkheadFull = errors.New("circuit breaker: bulkhead full")
+
+// PanicError is returned by Execute when the wrapped function panics and
+// Config.RecoverPanics is set. The panic is recorded as a failure.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value any
+
+	// Stack is the stack trace of the panicking goroutine.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("circuit breaker: recovered panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
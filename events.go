@@ -0,0 +1,145 @@
+package circuitbreaker
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventType identifies the kind of an Event.
+type EventType int
+
+const (
+	// EventStateChange is published when the breaker changes state.
+	// From and To are set.
+	EventStateChange EventType = iota
+
+	// EventRejected is published when the breaker rejects a call.
+	// Err is the rejection error.
+	EventRejected
+
+	// EventSlowCall is published when a call takes longer than
+	// SlowCallDuration. Duration is set.
+	EventSlowCall
+
+	// EventProbeResult is published when a Half-Open probe completes.
+	// Success is set, and Err holds the probe's error if it had one.
+	EventProbeResult
+
+	// EventReset is published when the breaker is reset with Reset.
+	EventReset
+
+	// EventEvicted is published when a Registry evicts an idle breaker.
+	EventEvicted
+)
+
+// String returns the string representation of an EventType.
+func (t EventType) String() string {
+	switch t {
+	case EventStateChange:
+		return "state-change"
+	case EventRejected:
+		return "rejected"
+	case EventSlowCall:
+		return "slow-call"
+	case EventProbeResult:
+		return "probe-result"
+	case EventReset:
+		return "reset"
+	case EventEvicted:
+		return "evicted"
+	default:
+		return "unknown"
+	}
+}
+
+// Event describes something that happened to a circuit breaker. Only the
+// fields documented for its Type are set.
+type Event struct {
+	Type EventType
+	Name string
+	Time time.Time
+
+	From     State
+	To       State
+	Err      error
+	Duration time.Duration
+	Success  bool
+}
+
+// Subscription delivers events to a single subscriber.
+//
+// Events are delivered asynchronously through a buffered channel. Publishers
+// never block: if the channel's buffer is full when an event is published,
+// that event is dropped for this subscriber and counted in Dropped.
+type Subscription struct {
+	ch      chan Event
+	dropped atomic.Int64
+	bus     *eventBus
+	once    sync.Once
+}
+
+// Events returns the channel events are delivered on. It is closed by Close.
+func (s *Subscription) Events() <-chan Event {
+	return s.ch
+}
+
+// Dropped returns the number of events dropped because the buffer was full.
+func (s *Subscription) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Close stops delivery and closes the events channel. It is safe to call
+// more than once.
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.bus.unsubscribe(s)
+	})
+}
+
+// eventBus fans events out to its subscribers. The zero value is ready
+// to use.
+type eventBus struct {
+	mu   sync.RWMutex
+	subs map[*Subscription]struct{}
+}
+
+// subscribe registers a subscriber whose channel buffers up to buffer
+// events. A buffer below 1 is treated as 1.
+func (b *eventBus) subscribe(buffer int) *Subscription {
+	if buffer < 1 {
+		buffer = 1
+	}
+	s := &Subscription{ch: make(chan Event, buffer), bus: b}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.subs == nil {
+		b.subs = make(map[*Subscription]struct{})
+	}
+	b.subs[s] = struct{}{}
+	return s
+}
+
+func (b *eventBus) unsubscribe(s *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.subs, s)
+	close(s.ch)
+}
+
+// publish delivers ev to every subscriber without blocking.
+func (b *eventBus) publish(ev Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for s := range b.subs {
+		select {
+		case s.ch <- ev:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// drain returns the events currently buffered in s.
+func drain(s *Subscription) []Event {
+	var out []Event
+	for {
+		select {
+		case ev := <-s.Events():
+			out = append(out, ev)
+		default:
+			return out
+		}
+	}
+}
+
+func TestEvents(t *testing.T) {
+	t.Parallel()
+
+	t.Run("state changes, rejections and probes are published", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newTestBreaker(Config{
+			Name:            "events",
+			WindowSize:      5,
+			MinRequests:     5,
+			RecoveryTimeout: 10 * time.Second,
+			ProbeCount:      1,
+		})
+		sub := cb.Subscribe(32)
+		defer sub.Close()
+
+		for i := 0; i < 5; i++ {
+			cb.Execute(context.Background(), failFn)
+		}
+		cb.Execute(context.Background(), succeedFn)
+		fc.Advance(11 * time.Second)
+		cb.Execute(context.Background(), succeedFn)
+
+		got := drain(sub)
+		want := []EventType{
+			EventStateChange, // closed → open
+			EventRejected,
+			EventStateChange, // open → half-open
+			EventProbeResult,
+			EventStateChange, // half-open → closed
+		}
+		if len(got) != len(want) {
+			t.Fatalf("got %d events %v, want %v", len(got), got, want)
+		}
+		for i, ev := range got {
+			if ev.Type != want[i] {
+				t.Fatalf("event %d = %v, want %v", i, ev.Type, want[i])
+			}
+			if ev.Name != "events" {
+				t.Fatalf("event %d Name = %q, want events", i, ev.Name)
+			}
+		}
+		if got[0].From != StateClosed || got[0].To != StateOpen {
+			t.Errorf("first transition = %v→%v, want closed→open", got[0].From, got[0].To)
+		}
+		if !errors.Is(got[1].Err, ErrCircuitOpen) {
+			t.Errorf("rejection Err = %v, want ErrCircuitOpen", got[1].Err)
+		}
+		if !got[3].Success {
+			t.Errorf("probe Success = false, want true")
+		}
+	})
+
+	t.Run("slow calls and resets are published", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newTestBreaker(Config{Name: "test", SlowCallDuration: time.Second})
+		sub := cb.Subscribe(8)
+		defer sub.Close()
+
+		cb.Execute(context.Background(), func(context.Context) (any, error) {
+			fc.Advance(3 * time.Second)
+			return nil, nil
+		})
+		cb.Reset()
+
+		got := drain(sub)
+		if len(got) != 2 || got[0].Type != EventSlowCall || got[1].Type != EventReset {
+			t.Fatalf("events = %v, want slow-call then reset", got)
+		}
+		if got[0].Duration != 3*time.Second {
+			t.Errorf("Duration = %v, want 3s", got[0].Duration)
+		}
+	})
+
+	t.Run("full buffers drop events without blocking", func(t *testing.T) {
+		t.Parallel()
+		cb, _ := newTestBreaker(Config{Name: "test", RecoveryTimeout: time.Minute})
+		sub := cb.Subscribe(2)
+		defer sub.Close()
+
+		cb.ForceOpen()
+		for i := 0; i < 10; i++ {
+			cb.Execute(context.Background(), succeedFn)
+		}
+
+		if got := len(drain(sub)); got != 2 {
+			t.Fatalf("buffered events = %d, want 2", got)
+		}
+		if got := sub.Dropped(); got != 9 {
+			t.Fatalf("Dropped() = %d, want 9", got)
+		}
+	})
+
+	t.Run("subscribers may call back into the breaker", func(t *testing.T) {
+		t.Parallel()
+		cb, _ := newTestBreaker(Config{Name: "test", WindowSize: 5, MinRequests: 5})
+		sub := cb.Subscribe(8)
+		defer sub.Close()
+
+		states := make(chan State, 1)
+		go func() {
+			for ev := range sub.Events() {
+				if ev.Type == EventStateChange {
+					states <- cb.State()
+					return
+				}
+			}
+		}()
+
+		for i := 0; i < 5; i++ {
+			cb.Execute(context.Background(), failFn)
+		}
+
+		select {
+		case st := <-states:
+			if st != StateOpen {
+				t.Fatalf("state = %v, want Open", st)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("subscriber did not observe the transition")
+		}
+	})
+
+	t.Run("Close stops delivery and closes the channel", func(t *testing.T) {
+		t.Parallel()
+		cb, _ := newTestBreaker(Config{Name: "test"})
+		sub := cb.Subscribe(8)
+
+		sub.Close()
+		sub.Close()
+		cb.ForceOpen()
+
+		if _, ok := <-sub.Events(); ok {
+			t.Fatal("received an event after Close")
+		}
+	})
+
+	t.Run("registry subscribers receive events from all breakers", func(t *testing.T) {
+		t.Parallel()
+		r := NewRegistry(Config{})
+		r.Get("a")
+		sub := r.Subscribe(8)
+		defer sub.Close()
+
+		r.Get("a").ForceOpen()
+		r.GetWithConfig("b", Config{}).Disable()
+
+		got := drain(sub)
+		if len(got) != 2 {
+			t.Fatalf("events = %v, want 2", got)
+		}
+		if got[0].Name != "a" || got[0].To != StateForcedOpen {
+			t.Errorf("first event = %+v, want a → forced-open", got[0])
+		}
+		if got[1].Name != "b" || got[1].To != StateDisabled {
+			t.Errorf("second event = %+v, want b → disabled", got[1])
+		}
+	})
+}
+
+func TestEventTypeString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		typ  EventType
+		want string
+	}{
+		{EventStateChange, "state-change"},
+		{EventRejected, "rejected"},
+		{EventSlowCall, "slow-call"},
+		{EventProbeResult, "probe-result"},
+		{EventReset, "reset"},
+		{EventEvicted, "evicted"},
+		{EventType(99), "unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.typ.String(); got != tt.want {
+			t.Errorf("EventType(%d).String() = %q, want %q", tt.typ, got, tt.want)
+		}
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	cb "github.com/awasame/circuitbreaker"
+	"github.com/awasame/circuitbreaker/cbprom"
 )
 
 // serviceConfig holds per-service failure probability, adjustable at runtime.
@@ -47,13 +48,7 @@ func main() {
 		MinRequests:      5,
 		RecoveryTimeout:  10 * time.Second,
 		ProbeCount:       3,
-		OnStateChange: func(name string, from, to cb.State) {
-			slog.Warn("circuit breaker state change",
-				"name", name,
-				"from", from.String(),
-				"to", to.String(),
-			)
-		},
+		Logger:           logger,
 	})
 
 	// Pre-create breakers so they appear in /api/status immediately.
@@ -117,6 +112,7 @@ func main() {
 				"total_requests":     m.TotalRequests,
 				"total_successes":    m.TotalSuccesses,
 				"total_failures":     m.TotalFailures,
+				"total_rejections":   m.TotalRejections,
 				"window_failure_rate": m.WindowFailureRate,
 				"last_state_change":  m.LastStateChange.Format(time.RFC3339),
 			}
@@ -126,6 +122,9 @@ func main() {
 		json.NewEncoder(w).Encode(status)
 	})
 
+	// GET /metrics (Prometheus text format)
+	mux.Handle("/metrics", cbprom.Handler(registry))
+
 	// POST /api/config  {"service": "service-a", "fail_rate": 0.8}
 	mux.HandleFunc("/api/config", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -3,11 +3,32 @@ package circuitbreaker
 import "time"
 
 // Metrics holds runtime statistics for a circuit breaker.
+//
+// Every request counted in TotalRequests ends up in exactly one of
+// TotalSuccesses, TotalFailures, TotalRejections or TotalIgnored (once it
+// has completed). TotalFallbacks counts the rejections served by the
+// Fallback; ProbeSuccesses and ProbeFailures count the Half-Open probes
+// among the successes and failures. RejectProbability is the chance that
+// the next call is rejected with ErrThrottled in ModeThrottle, and zero
+// in ModeBreaker. OpenDuration is the length of the current Open period,
+// including any RecoveryBackoff growth, and zero in every other state.
+// InFlight and Queued are the calls currently running and waiting for a
+// MaxConcurrent slot.
 type Metrics struct {
 	TotalRequests     int64
 	TotalSuccesses    int64
 	TotalFailures     int64
+	TotalRejections   int64
+	TotalFallbacks    int64
+	TotalIgnored      int64
+	ProbeSuccesses    int64
+	ProbeFailures     int64
 	CurrentState      State
 	LastStateChange   time.Time
 	WindowFailureRate float64
+	WindowSlowRate    float64
+	RejectProbability float64
+	OpenDuration      time.Duration
+	InFlight          int
+	Queued            int
 }
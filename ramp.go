@@ -0,0 +1,69 @@
+package circuitbreaker
+
+import (
+	"fmt"
+	"math"
+)
+
+// RampCurve shapes how the share of admitted requests grows while the
+// breaker is in StateRecovering.
+type RampCurve int
+
+const (
+	// RampLinear admits a share of requests proportional to the time
+	// elapsed in the ramp-up, from 0% to 100%.
+	RampLinear RampCurve = iota
+
+	// RampExponential starts at 1% and multiplies the admitted share by
+	// ten every half of the ramp-up, reaching 10% halfway and 100% at its
+	// end. It keeps the load low for longer than RampLinear.
+	RampExponential
+)
+
+// admission returns the share of requests to admit, from 0 to 1, after
+// progress (0 to 1) of the ramp-up.
+func (c RampCurve) admission(progress float64) float64 {
+	if progress >= 1 {
+		return 1
+	}
+	if progress <= 0 {
+		progress = 0
+	}
+	if c == RampExponential {
+		return math.Pow(100, progress-1)
+	}
+	return progress
+}
+
+// String returns "linear" or "exponential".
+func (c RampCurve) String() string {
+	switch c {
+	case RampLinear:
+		return "linear"
+	case RampExponential:
+		return "exponential"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText encodes c as its String form.
+func (c RampCurve) MarshalText() ([]byte, error) {
+	if c != RampLinear && c != RampExponential {
+		return nil, fmt.Errorf("circuit breaker: invalid RampCurve %d", int(c))
+	}
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText decodes the String form of a RampCurve.
+func (c *RampCurve) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "linear":
+		*c = RampLinear
+	case "exponential":
+		*c = RampExponential
+	default:
+		return fmt.Errorf("circuit breaker: unknown ramp curve %q", text)
+	}
+	return nil
+}
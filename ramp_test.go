@@ -0,0 +1,153 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRampCurve(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		curve    RampCurve
+		progress float64
+		want     float64
+	}{
+		{RampLinear, 0, 0},
+		{RampLinear, 0.25, 0.25},
+		{RampLinear, 1, 1},
+		{RampLinear, 3, 1},
+		{RampExponential, 0, 0.01},
+		{RampExponential, 0.5, 0.1},
+		{RampExponential, 1, 1},
+	}
+
+	for _, tt := range tests {
+		if got := tt.curve.admission(tt.progress); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%v.admission(%v) = %v, want %v", tt.curve, tt.progress, got, tt.want)
+		}
+	}
+}
+
+func TestCircuitBreakerRampUp(t *testing.T) {
+	t.Parallel()
+
+	// newRampBreaker returns a breaker that has just finished its
+	// Half-Open probes and is ramping up over 100s. Admission uses *rnd
+	// as its random number.
+	newRampBreaker := func(t *testing.T, curve RampCurve) (*CircuitBreaker, *fakeClock, *float64) {
+		t.Helper()
+		cb, fc := newTestBreaker(Config{
+			Name:            "test",
+			WindowSize:      5,
+			MinRequests:     5,
+			RecoveryTimeout: 10 * time.Second,
+			ProbeCount:      1,
+			RampUpDuration:  100 * time.Second,
+			RampUpCurve:     curve,
+			RecoveryBackoff: BackoffPolicy{Multiplier: 2},
+		})
+		rnd := new(float64)
+		cb.rand = func() float64 { return *rnd }
+
+		for i := 0; i < 5; i++ {
+			cb.Execute(context.Background(), failFn)
+		}
+		fc.Advance(10 * time.Second)
+		cb.Execute(context.Background(), succeedFn)
+		if cb.State() != StateRecovering {
+			t.Fatalf("state = %v, want Recovering", cb.State())
+		}
+		return cb, fc, rnd
+	}
+
+	t.Run("admitted share grows linearly", func(t *testing.T) {
+		t.Parallel()
+		cb, fc, rnd := newRampBreaker(t, RampLinear)
+
+		fc.Advance(30 * time.Second)
+		*rnd = 0.29
+		if _, err := cb.Execute(context.Background(), succeedFn); err != nil {
+			t.Fatalf("rnd 0.29 at 30%%: err = %v, want admitted", err)
+		}
+		*rnd = 0.31
+		if _, err := cb.Execute(context.Background(), succeedFn); !errors.Is(err, ErrRampingUp) {
+			t.Fatalf("rnd 0.31 at 30%%: err = %v, want ErrRampingUp", err)
+		}
+		if got := cb.Metrics().TotalRejections; got != 1 {
+			t.Fatalf("TotalRejections = %d, want 1", got)
+		}
+	})
+
+	t.Run("admitted share grows exponentially", func(t *testing.T) {
+		t.Parallel()
+		cb, fc, rnd := newRampBreaker(t, RampExponential)
+
+		fc.Advance(50 * time.Second)
+		*rnd = 0.2
+		if _, err := cb.Execute(context.Background(), succeedFn); !errors.Is(err, ErrRampingUp) {
+			t.Fatalf("rnd 0.2 at 10%%: err = %v, want ErrRampingUp", err)
+		}
+		*rnd = 0.09
+		if _, err := cb.Execute(context.Background(), succeedFn); err != nil {
+			t.Fatalf("rnd 0.09 at 10%%: err = %v, want admitted", err)
+		}
+	})
+
+	t.Run("Recovering→Closed: when the ramp-up ends", func(t *testing.T) {
+		t.Parallel()
+		cb, fc, rnd := newRampBreaker(t, RampLinear)
+
+		fc.Advance(99 * time.Second)
+		if cb.State() != StateRecovering {
+			t.Fatalf("state = %v, want Recovering", cb.State())
+		}
+		fc.Advance(time.Second)
+		if cb.State() != StateClosed {
+			t.Fatalf("state = %v, want Closed", cb.State())
+		}
+		*rnd = 0.999
+		if _, err := cb.Execute(context.Background(), succeedFn); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Recovering→Open: failure rate rises during the ramp-up", func(t *testing.T) {
+		t.Parallel()
+		cb, fc, _ := newRampBreaker(t, RampLinear)
+
+		fc.Advance(50 * time.Second)
+		for i := 0; i < 4; i++ {
+			cb.Execute(context.Background(), failFn)
+		}
+		if cb.State() != StateRecovering {
+			t.Fatalf("state = %v, want Recovering below MinRequests", cb.State())
+		}
+		cb.Execute(context.Background(), failFn)
+		if cb.State() != StateOpen {
+			t.Fatalf("state = %v, want Open", cb.State())
+		}
+
+		// The failed ramp-up counts as a re-open for RecoveryBackoff.
+		if got := cb.RemainingOpenTime(); got != 20*time.Second {
+			t.Fatalf("RemainingOpenTime = %v, want 20s", got)
+		}
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newTestBreaker(Config{Name: "test", WindowSize: 5, MinRequests: 5, ProbeCount: 1, RecoveryTimeout: time.Second})
+
+		for i := 0; i < 5; i++ {
+			cb.Execute(context.Background(), failFn)
+		}
+		fc.Advance(time.Second)
+		cb.Execute(context.Background(), succeedFn)
+		if cb.State() != StateClosed {
+			t.Fatalf("state = %v, want Closed", cb.State())
+		}
+	})
+}
@@ -1,13 +1,24 @@
 package circuitbreaker
 
-import "sync"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
 
 // Registry manages a collection of named circuit breakers.
 // It is safe for concurrent use.
 type Registry struct {
-	mu        sync.RWMutex
-	breakers  map[string]*CircuitBreaker
+	mu         sync.RWMutex
+	breakers   map[string]*CircuitBreaker
 	defaultCfg Config
+	overrides  map[string]Config
+	explicit   map[string]bool // created by GetWithConfig or Replace
+	events     eventBus
+	sweeper    *sweeper
 }
 
 // NewRegistry creates a Registry that uses defaultCfg for breakers
@@ -16,12 +27,62 @@ func NewRegistry(defaultCfg Config) *Registry {
 	return &Registry{
 		breakers:   make(map[string]*CircuitBreaker),
 		defaultCfg: defaultCfg,
+		explicit:   make(map[string]bool),
 	}
 }
 
+// LoadRegistry creates a Registry from a JSON document of the form
+//
+//	{
+//	  "defaults": {"failure_threshold": 0.5, "recovery_timeout": "30s"},
+//	  "breakers": {
+//	    "payment-api": {"failure_threshold": 0.2}
+//	  }
+//	}
+//
+// using the encoding of Config.MarshalJSON. The defaults are decoded over
+// base, which supplies the fields JSON cannot express, such as Logger,
+// IsFailure or TripStrategy. Each entry of "breakers" is decoded over the defaults and
+// becomes an override, as with Reconfigure. Unknown keys and invalid
+// values are reported as errors naming the breaker they belong to.
+func LoadRegistry(r io.Reader, base Config) (*Registry, error) {
+	var doc struct {
+		Defaults json.RawMessage            `json:"defaults"`
+		Breakers map[string]json.RawMessage `json:"breakers"`
+	}
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("circuit breaker: decoding registry config: %w", err)
+	}
+
+	defaults := base
+	if doc.Defaults != nil {
+		if err := json.Unmarshal(doc.Defaults, &defaults); err != nil {
+			return nil, fmt.Errorf("circuit breaker: decoding defaults: %w", err)
+		}
+	}
+	overrides := make(map[string]Config, len(doc.Breakers))
+	for name, raw := range doc.Breakers {
+		cfg := defaults
+		if err := json.Unmarshal(raw, &cfg); err != nil {
+			return nil, fmt.Errorf("circuit breaker: decoding breaker %s: %w", name, err)
+		}
+		overrides[name] = cfg
+	}
+
+	if err := validateAll(defaults, overrides); err != nil {
+		return nil, err
+	}
+	reg := NewRegistry(defaults)
+	reg.overrides = overrides
+	return reg, nil
+}
+
 // Get returns the circuit breaker registered under name, creating one
-// with the default config if it does not exist. The Name field of the
-// config is set to name automatically.
+// with the default config, or the override for name set by Reconfigure,
+// if it does not exist. The Name field of the config is set to name
+// automatically.
 func (r *Registry) Get(name string) *CircuitBreaker {
 	r.mu.RLock()
 	cb, ok := r.breakers[name]
@@ -38,9 +99,11 @@ func (r *Registry) Get(name string) *CircuitBreaker {
 		return cb
 	}
 
-	cfg := r.defaultCfg
-	cfg.Name = name
-	cb = New(cfg)
+	cfg, ok := r.overrides[name]
+	if !ok {
+		cfg = r.defaultCfg
+	}
+	cb = r.newBreaker(name, cfg)
 	r.breakers[name] = cb
 	return cb
 }
@@ -63,12 +126,103 @@ func (r *Registry) GetWithConfig(name string, cfg Config) *CircuitBreaker {
 		return cb
 	}
 
+	cb = r.newBreaker(name, cfg)
+	r.breakers[name] = cb
+	r.explicit[name] = true
+	return cb
+}
+
+// newBreaker creates a breaker named name that publishes its events to
+// the registry.
+func (r *Registry) newBreaker(name string, cfg Config) *CircuitBreaker {
 	cfg.Name = name
-	cb = New(cfg)
+	cb := New(cfg)
+	cb.parentBus = &r.events
+	return cb
+}
+
+// Remove deletes the breaker registered under name and stops its timers.
+// It reports whether a breaker was removed. Callers still holding the
+// breaker can keep using it, but a later Get creates a new one.
+func (r *Registry) Remove(name string) bool {
+	r.mu.Lock()
+	cb, ok := r.breakers[name]
+	delete(r.breakers, name)
+	delete(r.explicit, name)
+	r.mu.Unlock()
+
+	if ok {
+		cb.Close()
+	}
+	return ok
+}
+
+// Replace registers a new breaker created with cfg under name, discarding
+// the existing one, if any, along with its state and metrics.
+func (r *Registry) Replace(name string, cfg Config) *CircuitBreaker {
+	cb := r.newBreaker(name, cfg)
+
+	r.mu.Lock()
+	old := r.breakers[name]
 	r.breakers[name] = cb
+	r.explicit[name] = true
+	r.mu.Unlock()
+
+	if old != nil {
+		old.Close()
+	}
 	return cb
 }
 
+// Reconfigure replaces the default config and the per-breaker overrides,
+// and applies them with UpdateConfig to every registered breaker: the
+// override for its name if there is one, the defaults otherwise. Breakers
+// created with GetWithConfig or Replace keep their config unless an
+// override names them. Breakers created later by Get use the new configs
+// too. All configs are validated first; if any is invalid, nothing is
+// changed. Other registry calls wait until every breaker has been updated.
+func (r *Registry) Reconfigure(defaults Config, overrides map[string]Config) error {
+	if err := validateAll(defaults, overrides); err != nil {
+		return err
+	}
+
+	ov := make(map[string]Config, len(overrides))
+	for name, cfg := range overrides {
+		ov[name] = cfg
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.defaultCfg = defaults
+	r.overrides = ov
+	for name, cb := range r.breakers {
+		cfg, ok := ov[name]
+		if !ok {
+			if r.explicit[name] {
+				continue
+			}
+			cfg = defaults
+		}
+		cb.mu.Lock()
+		cb.applyConfig(cfg)
+		cb.mu.Unlock()
+	}
+	return nil
+}
+
+// validateAll validates defaults and every override, prefixing the errors
+// of an override with its name.
+func validateAll(defaults Config, overrides map[string]Config) error {
+	errs := []error{defaults.Validate()}
+	for name, cfg := range overrides {
+		if err := cfg.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // All returns a snapshot of all registered circuit breakers keyed by name.
 func (r *Registry) All() map[string]*CircuitBreaker {
 	r.mu.RLock()
@@ -80,3 +234,106 @@ func (r *Registry) All() map[string]*CircuitBreaker {
 	}
 	return out
 }
+
+// Subscribe returns a subscription to the events of every breaker in the
+// registry, including breakers created after the call, buffering up to
+// buffer undelivered events. See Subscription for the drop policy.
+func (r *Registry) Subscribe(buffer int) *Subscription {
+	return r.events.subscribe(buffer)
+}
+
+// EvictIdle removes every breaker that is in StateClosed, has no call in
+// flight and has not been called for at least ttl, as measured by its own
+// Clock, publishing an EventEvicted for each, and returns the names of
+// the evicted breakers. Breakers in any other state are kept so that a
+// tripped breaker is not silently reset.
+func (r *Registry) EvictIdle(ttl time.Duration) []string {
+	r.mu.Lock()
+	var evicted []*CircuitBreaker
+	for name, cb := range r.breakers {
+		if d, idle := cb.idleFor(); idle && d >= ttl {
+			delete(r.breakers, name)
+			delete(r.explicit, name)
+			evicted = append(evicted, cb)
+		}
+	}
+	r.mu.Unlock()
+
+	names := make([]string, 0, len(evicted))
+	for _, cb := range evicted {
+		cb.Close()
+		cb.mu.Lock()
+		cb.emit(Event{Type: EventEvicted})
+		names = append(names, cb.cfg.Name)
+		cb.mu.Unlock()
+	}
+	return names
+}
+
+// StartIdleEviction starts a background sweeper that calls EvictIdle(ttl)
+// every interval, using the default config's Clock. Starting a new
+// sweeper stops the previous one. The returned function stops the
+// sweeper; it is safe to call more than once. Like time.NewTicker, it
+// panics if ttl or interval is not positive.
+func (r *Registry) StartIdleEviction(ttl, interval time.Duration) (stop func()) {
+	if ttl <= 0 || interval <= 0 {
+		panic("circuit breaker: non-positive ttl or interval for StartIdleEviction")
+	}
+	s := &sweeper{clock: r.clock(), interval: interval}
+	s.run = func() {
+		r.EvictIdle(ttl)
+		s.schedule()
+	}
+
+	r.mu.Lock()
+	prev := r.sweeper
+	r.sweeper = s
+	r.mu.Unlock()
+
+	if prev != nil {
+		prev.stop()
+	}
+	s.schedule()
+	return s.stop
+}
+
+// clock returns the Clock of the default config.
+func (r *Registry) clock() Clock {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.defaultCfg.Clock != nil {
+		return r.defaultCfg.Clock
+	}
+	return systemClock{}
+}
+
+// sweeper re-arms a timer every interval until stopped.
+type sweeper struct {
+	clock    Clock
+	interval time.Duration
+	run      func()
+
+	mu      sync.Mutex
+	timer   Timer
+	stopped bool
+}
+
+func (s *sweeper) schedule() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.stopped {
+		s.timer = s.clock.AfterFunc(s.interval, s.run)
+	}
+}
+
+func (s *sweeper) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stopped = true
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+}
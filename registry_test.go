@@ -1,8 +1,12 @@
 package circuitbreaker
 
 import (
+	"context"
+	"sort"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestRegistry(t *testing.T) {
@@ -141,4 +145,316 @@ func TestRegistry(t *testing.T) {
 			t.Fatalf("len(All()) = %d, want %d", len(all), len(names))
 		}
 	})
+
+	t.Run("Remove: deletes the breaker", func(t *testing.T) {
+		t.Parallel()
+		r := NewRegistry(Config{})
+
+		old := r.Get("svc")
+		if !r.Remove("svc") {
+			t.Fatal("Remove returned false for an existing breaker")
+		}
+		if r.Remove("svc") {
+			t.Fatal("Remove returned true for a missing breaker")
+		}
+		if len(r.All()) != 0 {
+			t.Fatalf("len(All()) = %d, want 0", len(r.All()))
+		}
+		if r.Get("svc") == old {
+			t.Fatal("Get after Remove returned the removed breaker")
+		}
+	})
+
+	t.Run("Remove: stops the breaker's timers", func(t *testing.T) {
+		t.Parallel()
+		fc := &fakeClock{t: time.Now()}
+		r := NewRegistry(Config{Clock: fc, AutoHalfOpen: true})
+
+		cb := r.Get("svc")
+		for i := 0; i < 5; i++ {
+			cb.Execute(context.Background(), failFn)
+		}
+		if fc.pending() != 1 {
+			t.Fatalf("pending timers = %d, want 1", fc.pending())
+		}
+
+		r.Remove("svc")
+		if fc.pending() != 0 {
+			t.Fatalf("pending timers = %d after Remove, want 0", fc.pending())
+		}
+	})
+
+	t.Run("Replace: swaps in a breaker with the new config", func(t *testing.T) {
+		t.Parallel()
+		r := NewRegistry(Config{WindowSize: 10})
+
+		old := r.Get("svc")
+		cb := r.Replace("svc", Config{WindowSize: 42})
+		if cb == old {
+			t.Fatal("Replace returned the old breaker")
+		}
+		if r.Get("svc") != cb {
+			t.Fatal("Get does not return the replacement")
+		}
+		if cb.cfg.WindowSize != 42 || cb.cfg.Name != "svc" {
+			t.Fatalf("cfg = {Name: %q, WindowSize: %d}, want {svc 42}", cb.cfg.Name, cb.cfg.WindowSize)
+		}
+	})
+
+	t.Run("EvictIdle: removes only idle Closed breakers", func(t *testing.T) {
+		t.Parallel()
+		fc := &fakeClock{t: time.Now()}
+		r := NewRegistry(Config{Clock: fc, WindowSize: 5, MinRequests: 5})
+		sub := r.Subscribe(8)
+		defer sub.Close()
+
+		r.Get("idle")
+		r.Get("tripped")
+		for i := 0; i < 5; i++ {
+			r.Get("tripped").Execute(context.Background(), failFn)
+		}
+		drain(sub)
+
+		fc.Advance(50 * time.Minute)
+		r.Get("busy").Execute(context.Background(), succeedFn)
+		fc.Advance(20 * time.Minute)
+		r.Get("busy").Execute(context.Background(), succeedFn)
+
+		evicted := r.EvictIdle(time.Hour)
+		if len(evicted) != 1 || evicted[0] != "idle" {
+			t.Fatalf("evicted = %v, want [idle]", evicted)
+		}
+
+		names := make([]string, 0)
+		for name := range r.All() {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if len(names) != 2 || names[0] != "busy" || names[1] != "tripped" {
+			t.Fatalf("remaining = %v, want [busy tripped]", names)
+		}
+
+		events := drain(sub)
+		if len(events) != 1 || events[0].Type != EventEvicted || events[0].Name != "idle" {
+			t.Fatalf("events = %v, want one eviction of idle", events)
+		}
+	})
+
+	t.Run("EvictIdle: keeps breakers with calls in flight", func(t *testing.T) {
+		t.Parallel()
+		fc := &fakeClock{t: time.Now()}
+		r := NewRegistry(Config{Clock: fc})
+
+		done, err := r.Get("slow").Allow()
+		if err != nil {
+			t.Fatalf("Allow: %v", err)
+		}
+		fc.Advance(time.Hour)
+		if evicted := r.EvictIdle(time.Minute); len(evicted) != 0 {
+			t.Fatalf("evicted = %v, want none while a call is in flight", evicted)
+		}
+
+		done(nil)
+		fc.Advance(time.Minute)
+		if evicted := r.EvictIdle(time.Minute); len(evicted) != 1 {
+			t.Fatalf("evicted = %v, want [slow] once the call is done", evicted)
+		}
+	})
+
+	t.Run("EvictIdle: measures idle time on each breaker's Clock", func(t *testing.T) {
+		t.Parallel()
+		regClock := &fakeClock{t: time.Now()}
+		ownClock := &fakeClock{t: time.Now().Add(-24 * time.Hour)}
+		r := NewRegistry(Config{Clock: regClock})
+		r.GetWithConfig("own", Config{Clock: ownClock})
+
+		regClock.Advance(2 * time.Hour)
+		if evicted := r.EvictIdle(time.Hour); len(evicted) != 0 {
+			t.Fatalf("evicted = %v, want none (own clock has not moved)", evicted)
+		}
+		ownClock.Advance(2 * time.Hour)
+		if evicted := r.EvictIdle(time.Hour); len(evicted) != 1 {
+			t.Fatalf("evicted = %v, want [own]", evicted)
+		}
+	})
+
+	t.Run("StartIdleEviction: sweeps until stopped", func(t *testing.T) {
+		t.Parallel()
+		fc := &fakeClock{t: time.Now()}
+		r := NewRegistry(Config{Clock: fc})
+
+		stop := r.StartIdleEviction(time.Hour, time.Minute)
+
+		r.Get("a")
+		fc.Advance(61 * time.Minute)
+		if len(r.All()) != 0 {
+			t.Fatalf("len(All()) = %d, want 0 after sweep", len(r.All()))
+		}
+
+		stop()
+		stop()
+		if fc.pending() != 0 {
+			t.Fatalf("pending timers = %d after stop, want 0", fc.pending())
+		}
+
+		r.Get("b")
+		fc.Advance(2 * time.Hour)
+		if len(r.All()) != 1 {
+			t.Fatalf("len(All()) = %d, want 1 (sweeper stopped)", len(r.All()))
+		}
+	})
+
+	t.Run("StartIdleEviction: rejects non-positive durations", func(t *testing.T) {
+		t.Parallel()
+		r := NewRegistry(Config{})
+
+		for _, d := range [][2]time.Duration{{0, time.Minute}, {time.Hour, 0}, {time.Hour, -time.Minute}} {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("StartIdleEviction(%v, %v) did not panic", d[0], d[1])
+					}
+				}()
+				r.StartIdleEviction(d[0], d[1])
+			}()
+		}
+	})
+
+	t.Run("Reconfigure: applies defaults and overrides to existing breakers", func(t *testing.T) {
+		t.Parallel()
+		r := NewRegistry(Config{WindowSize: 10, MinRequests: 10})
+		a := r.Get("a")
+		b := r.Get("b")
+
+		err := r.Reconfigure(
+			Config{WindowSize: 2, MinRequests: 2},
+			map[string]Config{"b": {WindowSize: 3, MinRequests: 3}},
+		)
+		if err != nil {
+			t.Fatalf("Reconfigure: %v", err)
+		}
+
+		for i := 0; i < 2; i++ {
+			a.Execute(context.Background(), failFn)
+			b.Execute(context.Background(), failFn)
+		}
+		if a.State() != StateOpen {
+			t.Errorf("a: state = %v, want Open", a.State())
+		}
+		if b.State() != StateClosed {
+			t.Errorf("b: state = %v, want Closed (override needs 3)", b.State())
+		}
+		if r.Get("a") != a {
+			t.Error("Reconfigure replaced the breaker instance")
+		}
+	})
+
+	t.Run("Reconfigure: keeps explicit configs unless overridden", func(t *testing.T) {
+		t.Parallel()
+		r := NewRegistry(Config{})
+		pay := r.GetWithConfig("pay", Config{WindowSize: 1, MinRequests: 1, FailureThreshold: 0.1})
+		ship := r.Replace("ship", Config{WindowSize: 1, MinRequests: 1, FailureThreshold: 0.1})
+		tax := r.GetWithConfig("tax", Config{WindowSize: 1, MinRequests: 1, FailureThreshold: 0.1})
+
+		err := r.Reconfigure(
+			Config{WindowSize: 10, MinRequests: 10},
+			map[string]Config{"tax": {WindowSize: 10, MinRequests: 10}},
+		)
+		if err != nil {
+			t.Fatalf("Reconfigure: %v", err)
+		}
+
+		for _, cb := range []*CircuitBreaker{pay, ship, tax} {
+			cb.Execute(context.Background(), failFn)
+		}
+		if pay.State() != StateOpen || ship.State() != StateOpen {
+			t.Errorf("states = %v, %v; want explicit configs kept and Open", pay.State(), ship.State())
+		}
+		if tax.State() != StateClosed {
+			t.Errorf("tax: state = %v, want Closed (override needs 10)", tax.State())
+		}
+	})
+
+	t.Run("Reconfigure: later Get uses the override", func(t *testing.T) {
+		t.Parallel()
+		r := NewRegistry(Config{})
+
+		if err := r.Reconfigure(Config{}, map[string]Config{"x": {MinRequests: 1}}); err != nil {
+			t.Fatalf("Reconfigure: %v", err)
+		}
+		cb := r.Get("x")
+		cb.Execute(context.Background(), failFn)
+		if cb.State() != StateOpen {
+			t.Fatalf("state = %v, want Open", cb.State())
+		}
+	})
+
+	t.Run("Reconfigure: invalid override changes nothing", func(t *testing.T) {
+		t.Parallel()
+		r := NewRegistry(Config{MinRequests: 5})
+		cb := r.Get("a")
+
+		err := r.Reconfigure(Config{MinRequests: 1}, map[string]Config{"bad": {WindowSize: -1}})
+		if err == nil || !strings.Contains(err.Error(), "bad") {
+			t.Fatalf("err = %v, want error naming the bad override", err)
+		}
+		cb.Execute(context.Background(), failFn)
+		if cb.State() != StateClosed {
+			t.Fatalf("state = %v, want Closed (old config kept)", cb.State())
+		}
+	})
+
+	t.Run("LoadRegistry: layers overrides over defaults over base", func(t *testing.T) {
+		t.Parallel()
+		fc := &fakeClock{t: time.Now()}
+		doc := `{
+			"defaults": {"min_requests": 2, "recovery_timeout": "10s"},
+			"breakers": {"strict": {"min_requests": 1}}
+		}`
+
+		r, err := LoadRegistry(strings.NewReader(doc), Config{Clock: fc, WindowSize: 4})
+		if err != nil {
+			t.Fatalf("LoadRegistry: %v", err)
+		}
+
+		strict := r.Get("strict")
+		strict.Execute(context.Background(), failFn)
+		if strict.State() != StateOpen {
+			t.Fatalf("strict: state = %v, want Open", strict.State())
+		}
+		if got := strict.RemainingOpenTime(); got != 10*time.Second {
+			t.Fatalf("strict: RemainingOpenTime = %v, want 10s from defaults", got)
+		}
+
+		other := r.Get("other")
+		other.Execute(context.Background(), failFn)
+		if other.State() != StateClosed {
+			t.Fatalf("other: state = %v, want Closed (MinRequests 2)", other.State())
+		}
+	})
+
+	t.Run("LoadRegistry: reports invalid values by breaker", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			doc  string
+			want []string
+		}{
+			{`{"breakers": {"a": {"failure_threshold": 1.5}}}`, []string{"a:", "FailureThreshold"}},
+			{`{"breakers": {"b": {"recovery_timeout": "x"}}}`, []string{"breaker b", `invalid duration "x"`}},
+			{`{"defaults": {"probe_count": -1}}`, []string{"ProbeCount"}},
+			{`{"default": {}}`, []string{"default"}},
+		}
+		for _, tt := range tests {
+			_, err := LoadRegistry(strings.NewReader(tt.doc), Config{})
+			if err == nil {
+				t.Errorf("LoadRegistry(%s): want error", tt.doc)
+				continue
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("LoadRegistry(%s) error = %q, want it to mention %q", tt.doc, err, want)
+				}
+			}
+		}
+	})
 }
@@ -0,0 +1,95 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// RetryPolicy describes how Retry repeats a failed call.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of calls, including the first.
+	// Default: 3.
+	MaxAttempts int
+
+	// Backoff is the delay before each retry, growing with every retry.
+	// Default: 100ms doubling each retry, with ±20% jitter.
+	Backoff BackoffPolicy
+
+	// Retryable reports whether a call that failed with err should be
+	// retried. It is not consulted for ErrCircuitOpen, which MaxOpenWait
+	// governs. Default: every error is retried.
+	Retryable func(err error) bool
+
+	// MaxOpenWait is the longest Retry waits for an Open breaker. When a
+	// call is rejected with ErrCircuitOpen, Retry stops and returns the
+	// rejection, unless the breaker admits probes again within
+	// MaxOpenWait, in which case it waits until then and retries.
+	// StateForcedOpen is never waited for. Default: 0.
+	MaxOpenWait time.Duration
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 3
+	}
+	if p.Backoff == (BackoffPolicy{}) {
+		p.Backoff = BackoffPolicy{Initial: 100 * time.Millisecond, Multiplier: 2, Jitter: 0.2}
+	}
+	if p.Retryable == nil {
+		p.Retryable = func(error) bool { return true }
+	}
+	return p
+}
+
+// Retry runs fn through cb with Execute until it succeeds, policy gives
+// up, or ctx is done. Every attempt is a separate call for the breaker:
+// it is counted in Metrics and recorded in the sliding window, so a
+// retried outage trips the breaker as fast as independent calls would.
+// Retry returns the result and error of the last attempt, or ctx.Err()
+// if ctx is done while waiting to retry. Waits use cb's Clock.
+func Retry[T any](cb *CircuitBreaker, ctx context.Context, policy RetryPolicy, fn func(ctx context.Context) (T, error)) (T, error) {
+	policy = policy.withDefaults()
+
+	for attempt := 1; ; attempt++ {
+		result, err := Execute(cb, ctx, fn)
+		if err == nil || attempt >= policy.MaxAttempts || ctx.Err() != nil {
+			return result, err
+		}
+
+		var wait time.Duration
+		if errors.Is(err, ErrCircuitOpen) {
+			wait = cb.RemainingOpenTime()
+			if wait > policy.MaxOpenWait || cb.State() == StateForcedOpen {
+				return result, err
+			}
+		} else {
+			if !policy.Retryable(err) {
+				return result, err
+			}
+			wait = policy.Backoff.delay(attempt-1, cb.rand())
+		}
+
+		if err := sleep(ctx, cb.clock, wait); err != nil {
+			var zero T
+			return zero, err
+		}
+	}
+}
+
+// sleep waits for d on clock, returning ctx.Err() if ctx is done first.
+func sleep(ctx context.Context, clock Clock, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	done := make(chan struct{})
+	t := clock.AfterFunc(d, func() { close(done) })
+	defer t.Stop()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,163 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	t.Parallel()
+
+	// failing returns a function that fails n times, then succeeds.
+	failing := func(n int, calls *int) func(context.Context) (string, error) {
+		return func(context.Context) (string, error) {
+			*calls++
+			if *calls <= n {
+				return "", errBoom
+			}
+			return "ok", nil
+		}
+	}
+
+	// run calls Retry in a goroutine, advancing fc through every wait.
+	run := func(cb *CircuitBreaker, fc *fakeClock, ctx context.Context, policy RetryPolicy, fn func(context.Context) (string, error)) (string, error) {
+		type result struct {
+			v   string
+			err error
+		}
+		done := make(chan result, 1)
+		go func() {
+			v, err := Retry(cb, ctx, policy, fn)
+			done <- result{v, err}
+		}()
+		for {
+			select {
+			case r := <-done:
+				return r.v, r.err
+			case <-time.After(time.Millisecond):
+				fc.Advance(time.Second)
+			}
+		}
+	}
+
+	t.Run("retries until success, counting each attempt", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newTestBreaker(Config{Name: "test", WindowSize: 10, MinRequests: 10})
+
+		var calls int
+		got, err := run(cb, fc, context.Background(), RetryPolicy{MaxAttempts: 5}, failing(2, &calls))
+		if err != nil || got != "ok" {
+			t.Fatalf("Retry = %q, %v; want ok", got, err)
+		}
+		m := cb.Metrics()
+		if calls != 3 || m.TotalRequests != 3 || m.TotalFailures != 2 || m.TotalSuccesses != 1 {
+			t.Fatalf("calls = %d, metrics = %+v; want 3 attempts, 2 failures, 1 success", calls, m)
+		}
+	})
+
+	t.Run("stops after MaxAttempts", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newTestBreaker(Config{Name: "test", WindowSize: 10, MinRequests: 10})
+
+		var calls int
+		if _, err := run(cb, fc, context.Background(), RetryPolicy{MaxAttempts: 3}, failing(10, &calls)); !errors.Is(err, errBoom) {
+			t.Fatalf("err = %v, want errBoom", err)
+		}
+		if calls != 3 {
+			t.Fatalf("calls = %d, want 3", calls)
+		}
+	})
+
+	t.Run("non-retryable errors stop immediately", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newTestBreaker(Config{Name: "test"})
+
+		var calls int
+		policy := RetryPolicy{MaxAttempts: 5, Retryable: func(err error) bool { return !errors.Is(err, errBoom) }}
+		if _, err := run(cb, fc, context.Background(), policy, failing(10, &calls)); !errors.Is(err, errBoom) {
+			t.Fatalf("err = %v, want errBoom", err)
+		}
+		if calls != 1 {
+			t.Fatalf("calls = %d, want 1", calls)
+		}
+	})
+
+	t.Run("waits out the backoff on the breaker's clock", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newTestBreaker(Config{Name: "test", WindowSize: 10, MinRequests: 10})
+		start := fc.Now()
+
+		var calls int
+		policy := RetryPolicy{MaxAttempts: 3, Backoff: BackoffPolicy{Initial: 10 * time.Second, Multiplier: 2}}
+		if _, err := run(cb, fc, context.Background(), policy, failing(2, &calls)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if waited := fc.Now().Sub(start); waited < 30*time.Second {
+			t.Fatalf("waited %v, want at least 10s + 20s", waited)
+		}
+	})
+
+	t.Run("stops at ErrCircuitOpen beyond MaxOpenWait", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newTestBreaker(Config{Name: "test", WindowSize: 2, MinRequests: 2, RecoveryTimeout: time.Minute})
+
+		var calls int
+		policy := RetryPolicy{MaxAttempts: 10, MaxOpenWait: 30 * time.Second}
+		if _, err := run(cb, fc, context.Background(), policy, failing(10, &calls)); !errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("err = %v, want ErrCircuitOpen", err)
+		}
+		if calls != 2 {
+			t.Fatalf("calls = %d, want 2 (stopped once Open)", calls)
+		}
+	})
+
+	t.Run("waits for an Open breaker within MaxOpenWait", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newTestBreaker(Config{Name: "test", WindowSize: 2, MinRequests: 2, RecoveryTimeout: 5 * time.Second, ProbeCount: 1})
+
+		var calls int
+		policy := RetryPolicy{MaxAttempts: 10, MaxOpenWait: 10 * time.Second}
+		got, err := run(cb, fc, context.Background(), policy, failing(2, &calls))
+		if err != nil || got != "ok" {
+			t.Fatalf("Retry = %q, %v; want ok after the breaker recovers", got, err)
+		}
+		if m := cb.Metrics(); m.TotalRejections != 1 || m.CurrentState != StateClosed {
+			t.Fatalf("metrics = %+v, want 1 rejection and Closed", m)
+		}
+	})
+
+	t.Run("never waits for ForcedOpen", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newTestBreaker(Config{Name: "test"})
+		cb.ForceOpen()
+
+		var calls int
+		policy := RetryPolicy{MaxAttempts: 10, MaxOpenWait: time.Hour}
+		if _, err := run(cb, fc, context.Background(), policy, failing(0, &calls)); !errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("err = %v, want ErrCircuitOpen", err)
+		}
+		if m := cb.Metrics(); m.TotalRequests != 1 {
+			t.Fatalf("TotalRequests = %d, want 1", m.TotalRequests)
+		}
+	})
+
+	t.Run("context cancellation interrupts the wait", func(t *testing.T) {
+		t.Parallel()
+		cb, _ := newTestBreaker(Config{Name: "test"})
+		ctx, cancel := context.WithCancel(context.Background())
+
+		var calls int
+		done := make(chan error, 1)
+		go func() {
+			_, err := Retry(cb, ctx, RetryPolicy{MaxAttempts: 5}, failing(10, &calls))
+			done <- err
+		}()
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+		if err := <-done; !errors.Is(err, context.Canceled) {
+			t.Fatalf("err = %v, want context.Canceled", err)
+		}
+	})
+}
@@ -1,5 +1,7 @@
 package circuitbreaker
 
+import "fmt"
+
 // State represents the current state of a circuit breaker.
 type State int
 
@@ -13,9 +15,25 @@ const (
 	StateOpen
 
 	// StateHalfOpen allows a limited number of probe requests through.
-	// If all probes succeed, transitions to StateClosed.
+	// If all probes succeed, transitions to StateClosed, or to
+	// StateRecovering if RampUpDuration is set.
 	// If any probe fails, transitions back to StateOpen.
 	StateHalfOpen
+
+	// StateForcedOpen rejects all requests with ErrCircuitOpen until the
+	// override is cleared with ForceClosed or Reset. It never times out.
+	StateForcedOpen
+
+	// StateDisabled lets all requests through without recording their
+	// outcomes, until the override is cleared with ForceClosed or Reset.
+	StateDisabled
+
+	// StateRecovering follows a successful Half-Open period when
+	// RampUpDuration is set. It admits a growing share of requests,
+	// rejecting the rest with ErrRampingUp, and transitions to StateClosed
+	// when the ramp-up ends. If the TripStrategy fires in the meantime,
+	// transitions back to StateOpen.
+	StateRecovering
 )
 
 // String returns the string representation of a State.
@@ -27,7 +45,34 @@ func (s State) String() string {
 		return "open"
 	case StateHalfOpen:
 		return "half-open"
+	case StateForcedOpen:
+		return "forced-open"
+	case StateDisabled:
+		return "disabled"
+	case StateRecovering:
+		return "recovering"
 	default:
 		return "unknown"
 	}
 }
+
+// MarshalText encodes s as its String form, so that a State reads as
+// "open" rather than 1 in JSON.
+func (s State) MarshalText() ([]byte, error) {
+	name := s.String()
+	if name == "unknown" {
+		return nil, fmt.Errorf("circuit breaker: invalid State %d", int(s))
+	}
+	return []byte(name), nil
+}
+
+// UnmarshalText decodes the String form of a State.
+func (s *State) UnmarshalText(text []byte) error {
+	for st := StateClosed; st.String() != "unknown"; st++ {
+		if st.String() == string(text) {
+			*s = st
+			return nil
+		}
+	}
+	return fmt.Errorf("circuit breaker: unknown state %q", text)
+}
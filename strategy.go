@@ -0,0 +1,131 @@
+package circuitbreaker
+
+import "fmt"
+
+// Counts is the view of recent outcomes a TripStrategy decides on.
+type Counts struct {
+	// Requests is the number of outcomes in the sliding window.
+	Requests int
+
+	// Failures is the number of failed outcomes in the sliding window.
+	Failures int
+
+	// SlowCalls is the number of slow outcomes in the sliding window.
+	SlowCalls int
+
+	// ConsecutiveFailures is the number of failures recorded since the
+	// last success, regardless of the window. Ignored errors leave it
+	// unchanged.
+	ConsecutiveFailures int
+}
+
+// FailureRate returns Failures / Requests, or 0 if Requests is zero.
+func (c Counts) FailureRate() float64 {
+	if c.Requests == 0 {
+		return 0
+	}
+	return float64(c.Failures) / float64(c.Requests)
+}
+
+// SlowCallRate returns SlowCalls / Requests, or 0 if Requests is zero.
+func (c Counts) SlowCallRate() float64 {
+	if c.Requests == 0 {
+		return 0
+	}
+	return float64(c.SlowCalls) / float64(c.Requests)
+}
+
+// TripStrategy decides when a Closed breaker trips. ShouldTrip is called
+// after every outcome recorded while Closed. It runs while the breaker is
+// locked, so it must be fast and must not call back into the breaker.
+type TripStrategy interface {
+	ShouldTrip(c Counts) bool
+}
+
+// TripStrategyFunc adapts a function to the TripStrategy interface.
+type TripStrategyFunc func(c Counts) bool
+
+// ShouldTrip returns f(c).
+func (f TripStrategyFunc) ShouldTrip(c Counts) bool { return f(c) }
+
+// RatioStrategy trips when the window holds at least MinRequests outcomes
+// and the failure or slow-call ratio reaches its threshold. It is the
+// default strategy, built from FailureThreshold, MinRequests and, when
+// SlowCallDuration is set, SlowCallThreshold.
+type RatioStrategy struct {
+	// MinRequests is the number of outcomes required before tripping.
+	MinRequests int
+
+	// FailureThreshold is the failure ratio (0.0–1.0) that trips the
+	// breaker. Zero disables the check.
+	FailureThreshold float64
+
+	// SlowCallThreshold is the slow-call ratio (0.0–1.0) that trips the
+	// breaker. Zero disables the check.
+	SlowCallThreshold float64
+}
+
+// ShouldTrip implements TripStrategy.
+func (s RatioStrategy) ShouldTrip(c Counts) bool {
+	if c.Requests < s.MinRequests {
+		return false
+	}
+	if s.FailureThreshold > 0 && c.FailureRate() >= s.FailureThreshold {
+		return true
+	}
+	return s.SlowCallThreshold > 0 && c.SlowCallRate() >= s.SlowCallThreshold
+}
+
+func (s RatioStrategy) validate() error {
+	switch {
+	case s.MinRequests < 0:
+		return fmt.Errorf("RatioStrategy.MinRequests must not be negative, got %d", s.MinRequests)
+	case s.FailureThreshold < 0 || s.FailureThreshold > 1:
+		return fmt.Errorf("RatioStrategy.FailureThreshold must be between 0 and 1, got %v", s.FailureThreshold)
+	case s.SlowCallThreshold < 0 || s.SlowCallThreshold > 1:
+		return fmt.Errorf("RatioStrategy.SlowCallThreshold must be between 0 and 1, got %v", s.SlowCallThreshold)
+	}
+	return nil
+}
+
+// ConsecutiveFailuresStrategy trips after Threshold failures in a row,
+// however few calls there are. It suits low-traffic dependencies, where
+// a window rarely reaches MinRequests.
+type ConsecutiveFailuresStrategy struct {
+	// Threshold is the number of consecutive failures that trips the
+	// breaker. Must be positive.
+	Threshold int
+}
+
+// ShouldTrip implements TripStrategy.
+func (s ConsecutiveFailuresStrategy) ShouldTrip(c Counts) bool {
+	return c.ConsecutiveFailures >= s.Threshold
+}
+
+func (s ConsecutiveFailuresStrategy) validate() error {
+	if s.Threshold <= 0 {
+		return fmt.Errorf("ConsecutiveFailuresStrategy.Threshold must be positive, got %d", s.Threshold)
+	}
+	return nil
+}
+
+// FailureCountStrategy trips once the window holds Threshold failures,
+// whatever the number of successes. With WindowTimeBased this is an
+// absolute limit of failures per WindowDuration.
+type FailureCountStrategy struct {
+	// Threshold is the number of failures in the window that trips the
+	// breaker. Must be positive.
+	Threshold int
+}
+
+// ShouldTrip implements TripStrategy.
+func (s FailureCountStrategy) ShouldTrip(c Counts) bool {
+	return c.Failures >= s.Threshold
+}
+
+func (s FailureCountStrategy) validate() error {
+	if s.Threshold <= 0 {
+		return fmt.Errorf("FailureCountStrategy.Threshold must be positive, got %d", s.Threshold)
+	}
+	return nil
+}
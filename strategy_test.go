@@ -0,0 +1,148 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTripStrategies(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		strategy TripStrategy
+		counts   Counts
+		want     bool
+	}{
+		{"ratio: below MinRequests", RatioStrategy{MinRequests: 5, FailureThreshold: 0.5}, Counts{Requests: 4, Failures: 4}, false},
+		{"ratio: failure rate reached", RatioStrategy{MinRequests: 5, FailureThreshold: 0.5}, Counts{Requests: 10, Failures: 5}, true},
+		{"ratio: failure rate below", RatioStrategy{MinRequests: 5, FailureThreshold: 0.5}, Counts{Requests: 10, Failures: 4}, false},
+		{"ratio: slow-call rate reached", RatioStrategy{MinRequests: 5, FailureThreshold: 0.5, SlowCallThreshold: 0.3}, Counts{Requests: 10, SlowCalls: 3}, true},
+		{"ratio: slow-call check disabled", RatioStrategy{MinRequests: 5, FailureThreshold: 0.5}, Counts{Requests: 10, SlowCalls: 10}, false},
+		{"consecutive: streak reached", ConsecutiveFailuresStrategy{Threshold: 3}, Counts{Requests: 100, Failures: 3, ConsecutiveFailures: 3}, true},
+		{"consecutive: streak broken", ConsecutiveFailuresStrategy{Threshold: 3}, Counts{Requests: 10, Failures: 9, ConsecutiveFailures: 2}, false},
+		{"count: failures reached", FailureCountStrategy{Threshold: 10}, Counts{Requests: 1000, Failures: 10}, true},
+		{"count: failures below", FailureCountStrategy{Threshold: 10}, Counts{Requests: 9, Failures: 9}, false},
+		{"func", TripStrategyFunc(func(c Counts) bool { return c.SlowCalls > 1 }), Counts{SlowCalls: 2}, true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.strategy.ShouldTrip(tt.counts); got != tt.want {
+			t.Errorf("%s: ShouldTrip(%+v) = %v, want %v", tt.name, tt.counts, got, tt.want)
+		}
+	}
+}
+
+func TestCircuitBreakerTripStrategy(t *testing.T) {
+	t.Parallel()
+
+	t.Run("consecutive failures trip a low-traffic breaker", func(t *testing.T) {
+		t.Parallel()
+		cb, _ := newTestBreaker(Config{
+			Name:         "test",
+			TripStrategy: ConsecutiveFailuresStrategy{Threshold: 3},
+		})
+
+		cb.Execute(context.Background(), failFn)
+		cb.Execute(context.Background(), failFn)
+		cb.Execute(context.Background(), succeedFn)
+		cb.Execute(context.Background(), failFn)
+		cb.Execute(context.Background(), failFn)
+		if cb.State() != StateClosed {
+			t.Fatalf("state = %v, want Closed (streak broken by a success)", cb.State())
+		}
+
+		cb.Execute(context.Background(), failFn)
+		if cb.State() != StateOpen {
+			t.Fatalf("state = %v, want Open", cb.State())
+		}
+	})
+
+	t.Run("ignored errors do not break the streak", func(t *testing.T) {
+		t.Parallel()
+		errIgnored := errors.New("ignored")
+		cb, _ := newTestBreaker(Config{
+			Name:         "test",
+			TripStrategy: ConsecutiveFailuresStrategy{Threshold: 2},
+			IgnoreErrors: []error{errIgnored},
+		})
+
+		cb.Execute(context.Background(), failFn)
+		cb.Execute(context.Background(), func(context.Context) (any, error) { return nil, errIgnored })
+		cb.Execute(context.Background(), failFn)
+		if cb.State() != StateOpen {
+			t.Fatalf("state = %v, want Open", cb.State())
+		}
+	})
+
+	t.Run("streak restarts after recovery", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newTestBreaker(Config{
+			Name:            "test",
+			TripStrategy:    ConsecutiveFailuresStrategy{Threshold: 2},
+			RecoveryTimeout: time.Second,
+			ProbeCount:      1,
+		})
+
+		cb.Execute(context.Background(), failFn)
+		cb.Execute(context.Background(), failFn)
+		fc.Advance(time.Second)
+		cb.Execute(context.Background(), succeedFn)
+		if cb.State() != StateClosed {
+			t.Fatalf("state = %v, want Closed", cb.State())
+		}
+
+		cb.Execute(context.Background(), failFn)
+		if cb.State() != StateClosed {
+			t.Fatalf("state = %v, want Closed after one failure", cb.State())
+		}
+	})
+
+	t.Run("failure count in a time window", func(t *testing.T) {
+		t.Parallel()
+		cb, fc := newTestBreaker(Config{
+			Name:           "test",
+			WindowType:     WindowTimeBased,
+			WindowDuration: time.Minute,
+			WindowBuckets:  6,
+			TripStrategy:   FailureCountStrategy{Threshold: 3},
+		})
+
+		// Many successes do not dilute an absolute count.
+		for i := 0; i < 100; i++ {
+			cb.Execute(context.Background(), succeedFn)
+		}
+		cb.Execute(context.Background(), failFn)
+		cb.Execute(context.Background(), failFn)
+
+		// The first two failures expire before the third.
+		fc.Advance(2 * time.Minute)
+		cb.Execute(context.Background(), failFn)
+		if cb.State() != StateClosed {
+			t.Fatalf("state = %v, want Closed", cb.State())
+		}
+
+		cb.Execute(context.Background(), failFn)
+		cb.Execute(context.Background(), failFn)
+		if cb.State() != StateOpen {
+			t.Fatalf("state = %v, want Open", cb.State())
+		}
+	})
+
+	t.Run("Validate checks the shipped strategies", func(t *testing.T) {
+		t.Parallel()
+		for _, s := range []TripStrategy{
+			ConsecutiveFailuresStrategy{},
+			FailureCountStrategy{Threshold: -1},
+			RatioStrategy{FailureThreshold: 2},
+		} {
+			err := (&Config{TripStrategy: s}).Validate()
+			if err == nil || !strings.Contains(err.Error(), "TripStrategy") {
+				t.Errorf("Validate(%+v) = %v, want a TripStrategy error", s, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package circuitbreaker
+
+import "fmt"
+
+// Mode selects how a CircuitBreaker decides to reject calls.
+type Mode int
+
+const (
+	// ModeBreaker trips to StateOpen when the TripStrategy fires and
+	// rejects every call until the breaker recovers.
+	ModeBreaker Mode = iota
+
+	// ModeThrottle never trips. Instead it rejects each call with
+	// ErrThrottled with a probability that grows as the backend accepts
+	// fewer of the calls it is sent, as described in the "Handling
+	// Overload" chapter of Google's SRE book:
+	//
+	//	max(0, (requests - ThrottleK*accepts) / (requests + 1))
+	//
+	// requests counts the calls in a second window of the same type and
+	// size as the sliding window, including those rejected locally, and
+	// accepts counts those that succeeded.
+	ModeThrottle
+)
+
+// String returns "breaker" or "throttle".
+func (m Mode) String() string {
+	switch m {
+	case ModeBreaker:
+		return "breaker"
+	case ModeThrottle:
+		return "throttle"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText encodes m as its String form.
+func (m Mode) MarshalText() ([]byte, error) {
+	if m != ModeBreaker && m != ModeThrottle {
+		return nil, fmt.Errorf("circuit breaker: invalid Mode %d", int(m))
+	}
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText decodes the String form of a Mode.
+func (m *Mode) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "breaker":
+		*m = ModeBreaker
+	case "throttle":
+		*m = ModeThrottle
+	default:
+		return fmt.Errorf("circuit breaker: unknown mode %q", text)
+	}
+	return nil
+}
+
+// rejectProbability returns the probability that ModeThrottle rejects
+// the next call. The caller holds cb.mu.
+func (cb *CircuitBreaker) rejectProbability() float64 {
+	if cb.throttle == nil {
+		return 0
+	}
+	requests, fails, _ := cb.throttle.counts()
+	accepts := requests - fails
+	p := (float64(requests) - cb.cfg.ThrottleK*float64(accepts)) / float64(requests+1)
+	return max(0, p)
+}
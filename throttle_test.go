@@ -0,0 +1,146 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerThrottle(t *testing.T) {
+	t.Parallel()
+
+	// newThrottle returns a throttling breaker whose random number is *rnd.
+	newThrottle := func(cfg Config) (*CircuitBreaker, *fakeClock, *float64) {
+		cfg.Name = "test"
+		cfg.Mode = ModeThrottle
+		cb, fc := newTestBreaker(cfg)
+		rnd := new(float64)
+		cb.rand = func() float64 { return *rnd }
+		return cb, fc, rnd
+	}
+
+	t.Run("healthy backend is not throttled", func(t *testing.T) {
+		t.Parallel()
+		cb, _, _ := newThrottle(Config{WindowSize: 100})
+
+		for i := 0; i < 50; i++ {
+			if _, err := cb.Execute(context.Background(), succeedFn); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if got := cb.Metrics().RejectProbability; got != 0 {
+			t.Fatalf("RejectProbability = %v, want 0", got)
+		}
+	})
+
+	t.Run("reject probability follows the SRE formula", func(t *testing.T) {
+		t.Parallel()
+		cb, _, rnd := newThrottle(Config{WindowSize: 100, ThrottleK: 2})
+		*rnd = 0.999
+
+		// 10 accepted, 30 failed: (40 - 2*10) / 41.
+		for i := 0; i < 10; i++ {
+			cb.Execute(context.Background(), succeedFn)
+		}
+		for i := 0; i < 30; i++ {
+			cb.Execute(context.Background(), failFn)
+		}
+
+		want := 20.0 / 41.0
+		if got := cb.Metrics().RejectProbability; math.Abs(got-want) > 1e-9 {
+			t.Fatalf("RejectProbability = %v, want %v", got, want)
+		}
+		if cb.State() != StateClosed {
+			t.Fatalf("state = %v, want Closed (throttle never trips)", cb.State())
+		}
+	})
+
+	t.Run("calls are rejected with ErrThrottled and counted as requests", func(t *testing.T) {
+		t.Parallel()
+		cb, _, rnd := newThrottle(Config{WindowSize: 100, ThrottleK: 1})
+		*rnd = 0.999
+
+		for i := 0; i < 10; i++ {
+			cb.Execute(context.Background(), failFn)
+		}
+		// (10 - 0) / 11.
+		*rnd = 0.5
+		if _, err := cb.Execute(context.Background(), succeedFn); !errors.Is(err, ErrThrottled) {
+			t.Fatalf("err = %v, want ErrThrottled", err)
+		}
+		// The rejection raises the probability to 11/12.
+		m := cb.Metrics()
+		if want := 11.0 / 12.0; math.Abs(m.RejectProbability-want) > 1e-9 {
+			t.Fatalf("RejectProbability = %v, want %v", m.RejectProbability, want)
+		}
+		if m.TotalRejections != 1 {
+			t.Fatalf("TotalRejections = %d, want 1", m.TotalRejections)
+		}
+	})
+
+	t.Run("throttling eases as outcomes expire", func(t *testing.T) {
+		t.Parallel()
+		cb, fc, _ := newThrottle(Config{WindowType: WindowTimeBased, WindowDuration: 10 * time.Second, WindowBuckets: 10})
+
+		for i := 0; i < 10; i++ {
+			cb.Execute(context.Background(), failFn)
+		}
+		if cb.Metrics().RejectProbability == 0 {
+			t.Fatal("RejectProbability = 0, want > 0")
+		}
+		fc.Advance(10 * time.Second)
+		if got := cb.Metrics().RejectProbability; got != 0 {
+			t.Fatalf("RejectProbability = %v, want 0 after expiry", got)
+		}
+	})
+
+	t.Run("switching to throttle closes a tripped breaker", func(t *testing.T) {
+		t.Parallel()
+		cb, _ := newTestBreaker(Config{Name: "test", WindowSize: 5, MinRequests: 5})
+
+		for i := 0; i < 5; i++ {
+			cb.Execute(context.Background(), failFn)
+		}
+		if err := cb.UpdateConfig(Config{Mode: ModeThrottle}); err != nil {
+			t.Fatalf("UpdateConfig: %v", err)
+		}
+		if cb.State() != StateClosed {
+			t.Fatalf("state = %v, want Closed", cb.State())
+		}
+
+		if err := cb.UpdateConfig(Config{WindowSize: 5, MinRequests: 5}); err != nil {
+			t.Fatalf("UpdateConfig: %v", err)
+		}
+		if got := cb.Metrics().RejectProbability; got != 0 {
+			t.Fatalf("RejectProbability = %v, want 0 in ModeBreaker", got)
+		}
+	})
+
+	t.Run("Registry: mode can be set per dependency", func(t *testing.T) {
+		t.Parallel()
+		r := NewRegistry(Config{WindowSize: 5, MinRequests: 5})
+		if err := r.Reconfigure(Config{WindowSize: 5, MinRequests: 5}, map[string]Config{"flaky": {Mode: ModeThrottle}}); err != nil {
+			t.Fatalf("Reconfigure: %v", err)
+		}
+
+		for i := 0; i < 5; i++ {
+			r.Get("flaky").Execute(context.Background(), failFn)
+			r.Get("other").Execute(context.Background(), failFn)
+		}
+		if got := r.Get("flaky").State(); got != StateClosed {
+			t.Errorf("flaky: state = %v, want Closed", got)
+		}
+		if got := r.Get("other").State(); got != StateOpen {
+			t.Errorf("other: state = %v, want Open", got)
+		}
+	})
+
+	t.Run("Validate rejects ThrottleK below 1", func(t *testing.T) {
+		t.Parallel()
+		if err := (&Config{Mode: ModeThrottle, ThrottleK: 0.5}).Validate(); err == nil {
+			t.Fatal("Validate() = nil, want error")
+		}
+	})
+}
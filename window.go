@@ -1,19 +1,116 @@
 package circuitbreaker
 
-// outcome represents the result of a single call.
-type outcome bool
+import (
+	"fmt"
+	"time"
+)
+
+// WindowType selects how the sliding window bounds the outcomes it keeps.
+type WindowType int
+
+const (
+	// WindowCountBased keeps the last WindowSize outcomes in a ring buffer.
+	WindowCountBased WindowType = iota
+
+	// WindowTimeBased keeps the outcomes recorded during the last
+	// WindowDuration, aggregated into WindowBuckets buckets.
+	WindowTimeBased
+)
+
+// String returns "count-based" or "time-based".
+func (t WindowType) String() string {
+	switch t {
+	case WindowCountBased:
+		return "count-based"
+	case WindowTimeBased:
+		return "time-based"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText encodes t as its String form.
+func (t WindowType) MarshalText() ([]byte, error) {
+	if t != WindowCountBased && t != WindowTimeBased {
+		return nil, fmt.Errorf("circuit breaker: invalid WindowType %d", int(t))
+	}
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText decodes the String form of a WindowType.
+func (t *WindowType) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "count-based":
+		*t = WindowCountBased
+	case "time-based":
+		*t = WindowTimeBased
+	default:
+		return fmt.Errorf("circuit breaker: unknown window type %q", text)
+	}
+	return nil
+}
+
+// outcome represents the result of a single call as a set of flags.
+// A call can be both failed and slow.
+type outcome uint8
 
 const (
-	success outcome = true
-	failure outcome = false
+	success  outcome = 0
+	failure  outcome = 1 << 0
+	slowCall outcome = 1 << 1
 )
 
+// failed reports whether the call returned a failure.
+func (o outcome) failed() bool { return o&failure != 0 }
+
+// slow reports whether the call exceeded the slow-call duration.
+func (o outcome) slow() bool { return o&slowCall != 0 }
+
+// outcomeWindow is the set of recent outcomes the breaker trips on.
+// Implementations are not safe for concurrent use; the breaker guards
+// them with its mutex.
+type outcomeWindow interface {
+	record(o outcome)
+	failureRate() float64
+	slowRate() float64
+	total() int
+	counts() (total, fails, slows int)
+	reset()
+}
+
+// newWindow creates the window selected by cfg. now is consulted by
+// time-based windows to expire old buckets.
+func newWindow(cfg Config, now func() time.Time) outcomeWindow {
+	if cfg.WindowType == WindowTimeBased {
+		return newTimeWindow(cfg.WindowDuration, cfg.WindowBuckets, now)
+	}
+	return newSlidingWindow(cfg.WindowSize)
+}
+
+// resizeWindow returns a window for cfg that keeps the most recent
+// outcomes of w. If cfg selects a different WindowType, the returned
+// window is empty.
+func resizeWindow(w outcomeWindow, cfg Config, now func() time.Time) outcomeWindow {
+	switch w := w.(type) {
+	case *slidingWindow:
+		if cfg.WindowType == WindowCountBased {
+			return w.resize(cfg.WindowSize)
+		}
+	case *timeWindow:
+		if cfg.WindowType == WindowTimeBased {
+			return w.resize(cfg.WindowDuration, cfg.WindowBuckets)
+		}
+	}
+	return newWindow(cfg, now)
+}
+
 // slidingWindow is a fixed-size ring buffer that tracks call outcomes.
 type slidingWindow struct {
 	buf   []outcome
-	pos   int  // next write position
-	count int  // number of recorded outcomes (up to len(buf))
-	fails int  // number of failures currently in the window
+	pos   int // next write position
+	count int // number of recorded outcomes (up to len(buf))
+	fails int // number of failures currently in the window
+	slows int // number of slow calls currently in the window
 }
 
 // newSlidingWindow creates a sliding window with the given capacity.
@@ -30,19 +127,25 @@ func newSlidingWindow(size int) *slidingWindow {
 // the oldest entry is overwritten.
 func (w *slidingWindow) record(o outcome) {
 	if w.count == len(w.buf) {
-		// Overwriting oldest entry — adjust fails count.
+		// Overwriting oldest entry — adjust fails and slows counts.
 		old := w.buf[w.pos]
-		if old == failure {
+		if old.failed() {
 			w.fails--
 		}
+		if old.slow() {
+			w.slows--
+		}
 	} else {
 		w.count++
 	}
 
 	w.buf[w.pos] = o
-	if o == failure {
+	if o.failed() {
 		w.fails++
 	}
+	if o.slow() {
+		w.slows++
+	}
 
 	w.pos = (w.pos + 1) % len(w.buf)
 }
@@ -56,14 +159,209 @@ func (w *slidingWindow) failureRate() float64 {
 	return float64(w.fails) / float64(w.count)
 }
 
+// slowRate returns the ratio of slow calls to total recorded outcomes.
+// Returns 0 if no outcomes have been recorded.
+func (w *slidingWindow) slowRate() float64 {
+	if w.count == 0 {
+		return 0
+	}
+	return float64(w.slows) / float64(w.count)
+}
+
 // total returns the number of outcomes currently in the window.
 func (w *slidingWindow) total() int {
 	return w.count
 }
 
+// counts returns the number of outcomes, failures and slow calls
+// currently in the window.
+func (w *slidingWindow) counts() (total, fails, slows int) {
+	return w.count, w.fails, w.slows
+}
+
+// resize returns a window of the given capacity holding the most recent
+// outcomes of w, up to the new capacity.
+func (w *slidingWindow) resize(size int) *slidingWindow {
+	nw := newSlidingWindow(size)
+	keep := w.count
+	if keep > len(nw.buf) {
+		keep = len(nw.buf)
+	}
+	// Replay from oldest to newest so that pos ends up after the newest.
+	for i := keep; i > 0; i-- {
+		nw.record(w.buf[(w.pos-i+len(w.buf))%len(w.buf)])
+	}
+	return nw
+}
+
 // reset clears all recorded outcomes.
 func (w *slidingWindow) reset() {
 	w.pos = 0
 	w.count = 0
 	w.fails = 0
+	w.slows = 0
+}
+
+// bucket aggregates the outcomes recorded during one bucket interval.
+type bucket struct {
+	count int
+	fails int
+	slows int
+}
+
+// timeWindow tracks call outcomes over a fixed duration, split into
+// equal-width buckets. Buckets older than the window are expired lazily
+// whenever the window is read or written.
+type timeWindow struct {
+	now     func() time.Time
+	width   time.Duration // duration covered by a single bucket
+	buckets []bucket
+	head    int64 // index of the current bucket since the Unix epoch
+	started bool  // whether head has been initialised
+	count   int   // number of outcomes across live buckets
+	fails   int   // number of failures across live buckets
+	slows   int   // number of slow calls across live buckets
+}
+
+// newTimeWindow creates a time window covering d, split into n buckets.
+func newTimeWindow(d time.Duration, n int, now func() time.Time) *timeWindow {
+	if n <= 0 {
+		n = 1
+	}
+	width := d / time.Duration(n)
+	if width <= 0 {
+		width = 1
+	}
+	return &timeWindow{
+		now:     now,
+		width:   width,
+		buckets: make([]bucket, n),
+	}
+}
+
+// advance expires every bucket that has fallen out of the window since
+// the last call and moves head to the current bucket.
+func (w *timeWindow) advance() {
+	cur := w.now().UnixNano() / int64(w.width)
+	if !w.started {
+		w.head = cur
+		w.started = true
+		return
+	}
+	if cur <= w.head {
+		// Same bucket, or the clock went backwards — keep recording
+		// into the current head.
+		return
+	}
+
+	n := int64(len(w.buckets))
+	from := w.head + 1
+	if cur-w.head > n {
+		from = cur - n + 1
+	}
+	for i := from; i <= cur; i++ {
+		b := &w.buckets[w.index(i)]
+		w.count -= b.count
+		w.fails -= b.fails
+		w.slows -= b.slows
+		*b = bucket{}
+	}
+	w.head = cur
+}
+
+// index maps an absolute bucket number to a slot in buckets.
+func (w *timeWindow) index(i int64) int {
+	n := int64(len(w.buckets))
+	return int(((i % n) + n) % n)
+}
+
+// record adds an outcome to the current bucket.
+func (w *timeWindow) record(o outcome) {
+	w.advance()
+
+	b := &w.buckets[w.index(w.head)]
+	b.count++
+	w.count++
+	if o.failed() {
+		b.fails++
+		w.fails++
+	}
+	if o.slow() {
+		b.slows++
+		w.slows++
+	}
+}
+
+// failureRate returns the ratio of failures to total outcomes in the
+// buckets that have not expired. Returns 0 if there are none.
+func (w *timeWindow) failureRate() float64 {
+	w.advance()
+	if w.count == 0 {
+		return 0
+	}
+	return float64(w.fails) / float64(w.count)
+}
+
+// slowRate returns the ratio of slow calls to total outcomes in the
+// buckets that have not expired. Returns 0 if there are none.
+func (w *timeWindow) slowRate() float64 {
+	w.advance()
+	if w.count == 0 {
+		return 0
+	}
+	return float64(w.slows) / float64(w.count)
+}
+
+// total returns the number of outcomes in the buckets that have not expired.
+func (w *timeWindow) total() int {
+	w.advance()
+	return w.count
+}
+
+// counts returns the number of outcomes, failures and slow calls in the
+// buckets that have not expired.
+func (w *timeWindow) counts() (total, fails, slows int) {
+	w.advance()
+	return w.count, w.fails, w.slows
+}
+
+// resize returns a window covering d in n buckets that holds the
+// outcomes of w still within d. Each old bucket is moved whole into the
+// new bucket containing its start time.
+func (w *timeWindow) resize(d time.Duration, n int) *timeWindow {
+	nw := newTimeWindow(d, n, w.now)
+	w.advance()
+	nw.advance()
+
+	size := int64(len(w.buckets))
+	newSize := int64(len(nw.buckets))
+	for i := w.head - size + 1; i <= w.head; i++ {
+		b := w.buckets[w.index(i)]
+		if b.count == 0 {
+			continue
+		}
+		j := i * int64(w.width) / int64(nw.width)
+		if j <= nw.head-newSize || j > nw.head {
+			continue // outside the new window
+		}
+		nb := &nw.buckets[nw.index(j)]
+		nb.count += b.count
+		nb.fails += b.fails
+		nb.slows += b.slows
+		nw.count += b.count
+		nw.fails += b.fails
+		nw.slows += b.slows
+	}
+	return nw
+}
+
+// reset clears all recorded outcomes.
+func (w *timeWindow) reset() {
+	for i := range w.buckets {
+		w.buckets[i] = bucket{}
+	}
+	w.started = false
+	w.count = 0
+	w.fails = 0
+	w.slows = 0
 }
@@ -2,6 +2,7 @@ package circuitbreaker
 
 import (
 	"testing"
+	"time"
 )
 
 func TestSlidingWindow(t *testing.T) {
@@ -135,6 +136,30 @@ func TestSlidingWindow(t *testing.T) {
 		}
 	})
 
+	t.Run("slow calls are tracked independently of failures", func(t *testing.T) {
+		t.Parallel()
+		w := newSlidingWindow(4)
+
+		// [S+slow, F, F+slow, S] → 50% failures, 50% slow.
+		w.record(success | slowCall)
+		w.record(failure)
+		w.record(failure | slowCall)
+		w.record(success)
+
+		if got := w.failureRate(); got != 0.5 {
+			t.Errorf("failureRate() = %v, want 0.5", got)
+		}
+		if got := w.slowRate(); got != 0.5 {
+			t.Errorf("slowRate() = %v, want 0.5", got)
+		}
+
+		// Overwrite the first slow success → [S, F, F+slow, S] → 25% slow.
+		w.record(success)
+		if got := w.slowRate(); got != 0.25 {
+			t.Errorf("slowRate() = %v, want 0.25", got)
+		}
+	})
+
 	t.Run("reset clears window", func(t *testing.T) {
 		t.Parallel()
 		w := newSlidingWindow(5)
@@ -152,6 +177,40 @@ func TestSlidingWindow(t *testing.T) {
 		}
 	})
 
+	t.Run("resize keeps the most recent outcomes", func(t *testing.T) {
+		t.Parallel()
+		w := newSlidingWindow(4)
+
+		// [F, F, S, F] with pos wrapped once: oldest F is overwritten.
+		w.record(success)
+		w.record(failure)
+		w.record(failure)
+		w.record(success)
+		w.record(failure)
+
+		shrunk := w.resize(2)
+		if got := shrunk.total(); got != 2 {
+			t.Fatalf("total() = %v, want 2", got)
+		}
+		if got := shrunk.failureRate(); got != 0.5 {
+			t.Errorf("failureRate() = %v, want 0.5 (last two: S, F)", got)
+		}
+
+		grown := w.resize(8)
+		if got := grown.total(); got != 4 {
+			t.Fatalf("total() = %v, want 4", got)
+		}
+		if got := grown.failureRate(); got != 0.75 {
+			t.Errorf("failureRate() = %v, want 0.75", got)
+		}
+
+		// The next outcome evicts the oldest kept one (F) once full.
+		shrunk.record(success)
+		if got := shrunk.failureRate(); got != 0.5 {
+			t.Errorf("failureRate() after record = %v, want 0.5 (F, S)", got)
+		}
+	})
+
 	t.Run("zero or negative size defaults to 1", func(t *testing.T) {
 		t.Parallel()
 		w := newSlidingWindow(0)
@@ -162,3 +221,136 @@ func TestSlidingWindow(t *testing.T) {
 		}
 	})
 }
+
+func TestTimeWindow(t *testing.T) {
+	t.Parallel()
+
+	newTestWindow := func(d time.Duration, n int) (*timeWindow, *fakeClock) {
+		fc := &fakeClock{t: time.Unix(1_700_000_000, 0)}
+		return newTimeWindow(d, n, fc.Now), fc
+	}
+
+	t.Run("empty window has zero failure rate", func(t *testing.T) {
+		t.Parallel()
+		w, _ := newTestWindow(10*time.Second, 10)
+
+		if got := w.failureRate(); got != 0 {
+			t.Errorf("failureRate() = %v, want 0", got)
+		}
+		if got := w.total(); got != 0 {
+			t.Errorf("total() = %v, want 0", got)
+		}
+	})
+
+	t.Run("outcomes within the window are counted", func(t *testing.T) {
+		t.Parallel()
+		w, fc := newTestWindow(10*time.Second, 10)
+
+		w.record(failure)
+		fc.Advance(3 * time.Second)
+		w.record(success)
+		fc.Advance(3 * time.Second)
+		w.record(failure)
+		w.record(success)
+
+		if got := w.total(); got != 4 {
+			t.Errorf("total() = %v, want 4", got)
+		}
+		if got := w.failureRate(); got != 0.5 {
+			t.Errorf("failureRate() = %v, want 0.5", got)
+		}
+	})
+
+	t.Run("expired buckets are dropped", func(t *testing.T) {
+		t.Parallel()
+		w, fc := newTestWindow(10*time.Second, 10)
+
+		// Two failures at t=0, one success at t=5s.
+		w.record(failure)
+		w.record(failure)
+		fc.Advance(5 * time.Second)
+		w.record(success)
+
+		// At t=10s the t=0 bucket has expired.
+		fc.Advance(5 * time.Second)
+		if got := w.total(); got != 1 {
+			t.Fatalf("total() = %v, want 1", got)
+		}
+		if got := w.failureRate(); got != 0 {
+			t.Errorf("failureRate() = %v, want 0", got)
+		}
+
+		// At t=15s everything has expired.
+		fc.Advance(5 * time.Second)
+		if got := w.total(); got != 0 {
+			t.Errorf("total() = %v, want 0", got)
+		}
+	})
+
+	t.Run("long idle period clears the whole window", func(t *testing.T) {
+		t.Parallel()
+		w, fc := newTestWindow(time.Minute, 60)
+
+		for i := 0; i < 10; i++ {
+			w.record(failure)
+			fc.Advance(time.Second)
+		}
+		fc.Advance(40 * time.Minute)
+
+		if got := w.total(); got != 0 {
+			t.Errorf("total() = %v, want 0", got)
+		}
+		w.record(success)
+		if got := w.failureRate(); got != 0 {
+			t.Errorf("failureRate() = %v, want 0", got)
+		}
+	})
+
+	t.Run("resize keeps outcomes still within the new duration", func(t *testing.T) {
+		t.Parallel()
+		w, fc := newTestWindow(10*time.Second, 10)
+
+		// Failures at t=0 and t=4s, success at t=8s.
+		w.record(failure)
+		fc.Advance(4 * time.Second)
+		w.record(failure)
+		fc.Advance(4 * time.Second)
+		w.record(success)
+
+		shrunk := w.resize(6*time.Second, 3)
+		if got := shrunk.total(); got != 2 {
+			t.Fatalf("total() = %v, want 2 (t=0 is outside 6s)", got)
+		}
+		if got := shrunk.failureRate(); got != 0.5 {
+			t.Errorf("failureRate() = %v, want 0.5", got)
+		}
+
+		grown := w.resize(time.Minute, 60)
+		if got := grown.total(); got != 3 {
+			t.Fatalf("total() = %v, want 3", got)
+		}
+
+		// Outcomes keep expiring according to the new window.
+		fc.Advance(4 * time.Second)
+		if got := shrunk.total(); got != 1 {
+			t.Errorf("total() at t=12s = %v, want 1", got)
+		}
+	})
+
+	t.Run("reset clears window", func(t *testing.T) {
+		t.Parallel()
+		w, _ := newTestWindow(10*time.Second, 10)
+
+		for i := 0; i < 5; i++ {
+			w.record(failure)
+		}
+		w.reset()
+
+		if got := w.total(); got != 0 {
+			t.Errorf("total() = %v, want 0 after reset", got)
+		}
+		if got := w.failureRate(); got != 0 {
+			t.Errorf("failureRate() = %v, want 0 after reset", got)
+		}
+	})
+}